@@ -31,6 +31,13 @@ func NewServerCallMeta(spec connect.Spec, reqOrNil any) CallMeta {
 	c.Service, c.Method = splitFullMethodName(spec.Procedure)
 	return c
 }
+
+func NewClientCallMeta(spec connect.Spec, reqOrNil any) CallMeta {
+	c := CallMeta{IsClient: true, ReqOrNil: reqOrNil, Typ: spec.StreamType}
+	c.Service, c.Method = splitFullMethodName(spec.Procedure)
+	return c
+}
+
 func (c CallMeta) FullMethod() string {
 	return fmt.Sprintf("/%s/%s", c.Service, c.Method)
 }
@@ -0,0 +1,276 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+// ErrOpen is the cause of the connect.CodeUnavailable error returned for calls rejected by an open circuit.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit.
+type State int
+
+const (
+	// StateClosed lets every call through, the failure rate is tracked over the rolling window.
+	StateClosed State = iota
+	// StateOpen rejects every call until the open timeout elapsed.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through to find out whether the dependency recovered.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker is a client-side circuit breaker. Calls are grouped into circuits, one per procedure by default
+// (see WithKeyFunc). A circuit opens when the rate of failure codes over a rolling window crosses the configured
+// ratio, and calls then fail fast with connect.CodeUnavailable until probe calls succeed again.
+type Breaker struct {
+	opts *options
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// New returns a new Breaker.
+func New(opts ...Option) *Breaker {
+	return &Breaker{
+		opts:     evaluateOptions(opts),
+		circuits: map[string]*circuit{},
+	}
+}
+
+// State returns the current state of the circuit of the given key. Unknown circuits are closed.
+func (b *Breaker) State(key string) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		return StateClosed
+	}
+	return c.currentState(time.Now(), b.opts)
+}
+
+// States returns the current state of every circuit seen so far, e.g. to be shown on a health page.
+func (b *Breaker) States() map[string]State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	states := make(map[string]State, len(b.circuits))
+	for k, c := range b.circuits {
+		states[k] = c.currentState(now, b.opts)
+	}
+	return states
+}
+
+// UnaryClientInterceptor returns a new unary client interceptor failing fast while the circuit of the call is open.
+func (b *Breaker) UnaryClientInterceptor() connect.UnaryInterceptorFunc {
+	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
+		return connect.UnaryFunc(func(
+			ctx context.Context,
+			req connect.AnyRequest,
+		) (connect.AnyResponse, error) {
+			if !req.Spec().IsClient {
+				return next(ctx, req)
+			}
+			key := b.opts.keyFunc(req)
+			generation, ok := b.allow(ctx, key)
+			if !ok {
+				return nil, connect.NewError(connect.CodeUnavailable, ErrOpen)
+			}
+			resp, err := next(ctx, req)
+			b.record(ctx, key, generation, b.isFailure(err))
+			return resp, err
+		})
+	}
+	return connect.UnaryInterceptorFunc(interceptor)
+}
+
+func (b *Breaker) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	code := connect.CodeOf(err)
+	for _, c := range b.opts.failureCodes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// allow reports whether a call may go through the circuit of the given key, and the circuit generation the
+// outcome of the call must be recorded against.
+func (b *Breaker) allow(ctx context.Context, key string) (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		c = newCircuit(b.opts)
+		b.circuits[key] = c
+	}
+
+	now := time.Now()
+	switch c.state {
+	case StateOpen:
+		if now.Before(c.openedAt.Add(b.opts.openTimeout)) {
+			return 0, false
+		}
+		b.transition(ctx, key, c, StateHalfOpen, now)
+		fallthrough
+	case StateHalfOpen:
+		if c.probes >= b.opts.halfOpenRequests {
+			return 0, false
+		}
+		c.probes++
+	}
+	return c.generation, true
+}
+
+func (b *Breaker) record(ctx context.Context, key string, generation uint64, failure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[key]
+	if c.generation != generation {
+		// The call was let through before the last state transition, its outcome is stale.
+		return
+	}
+
+	now := time.Now()
+	switch c.state {
+	case StateClosed:
+		c.window.add(now, failure)
+		total, failures := c.window.sum(now)
+		if total >= b.opts.minRequests && float64(failures)/float64(total) >= b.opts.failureRatio {
+			b.transition(ctx, key, c, StateOpen, now)
+		}
+	case StateHalfOpen:
+		if failure {
+			b.transition(ctx, key, c, StateOpen, now)
+			return
+		}
+		c.successes++
+		if c.successes >= b.opts.halfOpenRequests {
+			b.transition(ctx, key, c, StateClosed, now)
+		}
+	}
+}
+
+func (b *Breaker) transition(ctx context.Context, key string, c *circuit, to State, now time.Time) {
+	from := c.state
+	c.state = to
+	c.generation++
+	c.probes, c.successes = 0, 0
+	switch to {
+	case StateOpen:
+		c.openedAt = now
+	case StateClosed:
+		c.window.reset()
+	}
+
+	if b.opts.logger == nil {
+		return
+	}
+	level := logging.LevelInfo
+	if to == StateOpen {
+		level = logging.LevelWarn
+	}
+	fields := logging.ExtractFields(ctx).AppendUnique(logging.Fields{
+		"circuit_breaker.key", key,
+		"circuit_breaker.from", from.String(),
+		"circuit_breaker.to", to.String(),
+	})
+	b.opts.logger.Log(ctx, level, "circuit breaker state changed", fields...)
+}
+
+type circuit struct {
+	state      State
+	generation uint64
+	openedAt   time.Time
+	probes     int
+	successes  int
+	window     *rollingWindow
+}
+
+func newCircuit(o *options) *circuit {
+	return &circuit{window: newRollingWindow(o.window, o.buckets)}
+}
+
+// currentState is the state as seen from the outside: an open circuit whose timeout elapsed is reported as
+// half-open even though the transition only happens on the next call.
+func (c *circuit) currentState(now time.Time, o *options) State {
+	if c.state == StateOpen && !now.Before(c.openedAt.Add(o.openTimeout)) {
+		return StateHalfOpen
+	}
+	return c.state
+}
+
+type bucket struct {
+	start    time.Time
+	total    uint64
+	failures uint64
+}
+
+// rollingWindow counts calls and failures over the last `window`, divided into buckets.
+type rollingWindow struct {
+	buckets []bucket
+	width   time.Duration
+}
+
+func newRollingWindow(window time.Duration, buckets int) *rollingWindow {
+	return &rollingWindow{buckets: make([]bucket, buckets), width: window / time.Duration(buckets)}
+}
+
+func (w *rollingWindow) add(now time.Time, failure bool) {
+	start := now.Truncate(w.width)
+	b := &w.buckets[int(start.UnixNano()/int64(w.width))%len(w.buckets)]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	b.total++
+	if failure {
+		b.failures++
+	}
+}
+
+func (w *rollingWindow) sum(now time.Time) (total, failures uint64) {
+	oldest := now.Truncate(w.width).Add(-w.width * time.Duration(len(w.buckets)-1))
+	for _, b := range w.buckets {
+		if b.start.Before(oldest) {
+			continue
+		}
+		total += b.total
+		failures += b.failures
+	}
+	return total, failures
+}
+
+func (w *rollingWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/circuitbreaker"
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+const procedure = "/test.v1.TestService/Echo"
+
+// server answers with code, or successfully if code is zero.
+type server struct {
+	*httptest.Server
+	code  atomic.Int32
+	calls atomic.Int32
+	// block, if set, holds calls until it is closed.
+	block chan struct{}
+}
+
+func newServer(t *testing.T) *server {
+	s := &server{}
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		s.calls.Add(1)
+		if s.block != nil {
+			<-s.block
+		}
+		if code := connect.Code(s.code.Load()); code != 0 {
+			return nil, connect.NewError(code, errors.New("failed"))
+		}
+		return connect.NewResponse(req.Msg), nil
+	}))
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+type recordingLogger struct {
+	mu          sync.Mutex
+	transitions []string
+}
+
+func (l *recordingLogger) Log(_ context.Context, _ logging.Level, _ string, fields ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var from, to any
+	for i := logging.Fields(fields).Iterator(); i.Next(); {
+		switch k, v := i.At(); k {
+		case "circuit_breaker.from":
+			from = v
+		case "circuit_breaker.to":
+			to = v
+		}
+	}
+	l.transitions = append(l.transitions, from.(string)+"->"+to.(string))
+}
+
+func newClient(url string, b *circuitbreaker.Breaker) func() error {
+	c := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		http.DefaultClient, url+procedure,
+		connect.WithInterceptors(b.UnaryClientInterceptor()),
+	)
+	return func() error {
+		_, err := c.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("hi")))
+		return err
+	}
+}
+
+func isOpenErr(err error) bool {
+	return connect.CodeOf(err) == connect.CodeUnavailable && errors.Is(err, circuitbreaker.ErrOpen)
+}
+
+func TestStateMachine(t *testing.T) {
+	s := newServer(t)
+	logger := &recordingLogger{}
+	b := circuitbreaker.New(
+		circuitbreaker.WithMinRequests(4),
+		circuitbreaker.WithFailureRatio(0.5),
+		circuitbreaker.WithOpenTimeout(50*time.Millisecond),
+		circuitbreaker.WithHalfOpenRequests(2),
+		circuitbreaker.WithLogger(logger),
+	)
+	call := newClient(s.URL, b)
+
+	s.code.Store(int32(connect.CodeUnavailable))
+	for i := 0; i < 3; i++ {
+		_ = call()
+		if got := b.State(procedure); got != circuitbreaker.StateClosed {
+			t.Fatalf("state after %d failures = %v, want closed below the minimum number of requests", i+1, got)
+		}
+	}
+	_ = call()
+	if got := b.State(procedure); got != circuitbreaker.StateOpen {
+		t.Fatalf("state = %v, want open", got)
+	}
+
+	calls := s.calls.Load()
+	if err := call(); !isOpenErr(err) {
+		t.Fatalf("err = %v, want the call rejected by the open circuit", err)
+	}
+	if s.calls.Load() != calls {
+		t.Fatal("the open circuit let a call through")
+	}
+
+	// A failed probe opens the circuit again.
+	time.Sleep(60 * time.Millisecond)
+	if got := b.State(procedure); got != circuitbreaker.StateHalfOpen {
+		t.Fatalf("state = %v, want half-open after the open timeout", got)
+	}
+	if err := call(); connect.CodeOf(err) != connect.CodeUnavailable || isOpenErr(err) {
+		t.Fatalf("err = %v, want the probe to reach the server", err)
+	}
+	if got := b.State(procedure); got != circuitbreaker.StateOpen {
+		t.Fatalf("state = %v, want open after a failed probe", got)
+	}
+
+	// All probes succeeding close the circuit.
+	time.Sleep(60 * time.Millisecond)
+	s.code.Store(0)
+	for i := 0; i < 2; i++ {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := b.State(procedure); got != circuitbreaker.StateClosed {
+		t.Fatalf("state = %v, want closed after successful probes", got)
+	}
+	if got := b.States(); len(got) != 1 || got[procedure] != circuitbreaker.StateClosed {
+		t.Fatalf("states = %v", got)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", logger.transitions, want)
+	}
+	for i := range want {
+		if logger.transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", logger.transitions, want)
+		}
+	}
+}
+
+func TestHalfOpenLimitsProbes(t *testing.T) {
+	s := newServer(t)
+	b := circuitbreaker.New(
+		circuitbreaker.WithMinRequests(1),
+		circuitbreaker.WithOpenTimeout(10*time.Millisecond),
+	)
+	call := newClient(s.URL, b)
+
+	s.code.Store(int32(connect.CodeInternal))
+	_ = call()
+	time.Sleep(20 * time.Millisecond)
+
+	s.code.Store(0)
+	s.block = make(chan struct{})
+	probe := make(chan error)
+	go func() { probe <- call() }()
+	for s.calls.Load() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := call(); !isOpenErr(err) {
+		t.Fatalf("err = %v, want the call rejected while the probe is in flight", err)
+	}
+	close(s.block)
+	if err := <-probe; err != nil {
+		t.Fatal(err)
+	}
+	if got := b.State(procedure); got != circuitbreaker.StateClosed {
+		t.Fatalf("state = %v, want closed", got)
+	}
+}
+
+func TestFailureCodes(t *testing.T) {
+	s := newServer(t)
+	b := circuitbreaker.New(circuitbreaker.WithMinRequests(1), circuitbreaker.WithFailureCodes(connect.CodeInternal))
+	call := newClient(s.URL, b)
+
+	s.code.Store(int32(connect.CodeUnavailable))
+	for i := 0; i < 5; i++ {
+		_ = call()
+	}
+	if got := b.State(procedure); got != circuitbreaker.StateClosed {
+		t.Fatalf("state = %v, want other codes not counted as failures", got)
+	}
+	s.code.Store(int32(connect.CodeInternal))
+	for i := 0; i < 5; i++ {
+		_ = call()
+	}
+	if got := b.State(procedure); got != circuitbreaker.StateOpen {
+		t.Fatalf("state = %v, want open", got)
+	}
+}
+
+func TestRollingWindowExpires(t *testing.T) {
+	s := newServer(t)
+	b := circuitbreaker.New(circuitbreaker.WithMinRequests(4), circuitbreaker.WithWindow(50*time.Millisecond, 5))
+	call := newClient(s.URL, b)
+
+	s.code.Store(int32(connect.CodeUnavailable))
+	for i := 0; i < 3; i++ {
+		_ = call()
+	}
+	time.Sleep(70 * time.Millisecond)
+	_ = call()
+	if got := b.State(procedure); got != circuitbreaker.StateClosed {
+		t.Fatalf("state = %v, want failures older than the window forgotten", got)
+	}
+}
+
+func TestWithWindowValidation(t *testing.T) {
+	for _, tc := range []struct {
+		window  time.Duration
+		buckets int
+	}{
+		{0, 10},
+		{5, 10},
+		{time.Second, 0},
+		{time.Second, -1},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("WithWindow(%v, %d): want a panic", tc.window, tc.buckets)
+				}
+			}()
+			circuitbreaker.WithWindow(tc.window, tc.buckets)
+		}()
+	}
+	circuitbreaker.New(circuitbreaker.WithWindow(10, 10))
+}
@@ -0,0 +1,128 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package circuitbreaker
+
+import (
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+var (
+	// DefaultFailureCodes are the codes counted as failures by the circuit breaker.
+	DefaultFailureCodes = []connect.Code{connect.CodeUnavailable, connect.CodeDeadlineExceeded, connect.CodeInternal}
+
+	defaultOptions = &options{
+		keyFunc:          KeyByProcedure,
+		failureCodes:     DefaultFailureCodes,
+		window:           10 * time.Second,
+		buckets:          10,
+		failureRatio:     0.5,
+		minRequests:      20,
+		openTimeout:      30 * time.Second,
+		halfOpenRequests: 1,
+	}
+)
+
+type options struct {
+	keyFunc          KeyFunc
+	failureCodes     []connect.Code
+	window           time.Duration
+	buckets          int
+	failureRatio     float64
+	minRequests      uint64
+	openTimeout      time.Duration
+	halfOpenRequests int
+	logger           logging.Logger
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// KeyFunc returns the key of the circuit a call belongs to.
+type KeyFunc func(req connect.AnyRequest) string
+
+// KeyByProcedure uses one circuit per procedure, e.g. "/ping.v1.PingService/Ping".
+func KeyByProcedure(req connect.AnyRequest) string {
+	return req.Spec().Procedure
+}
+
+// KeyByHost uses one circuit per host the client talks to.
+func KeyByHost(req connect.AnyRequest) string {
+	return req.Peer().Addr
+}
+
+// WithKeyFunc customizes how calls are grouped into circuits. Defaults to KeyByProcedure.
+func WithKeyFunc(f KeyFunc) Option {
+	return func(o *options) {
+		o.keyFunc = f
+	}
+}
+
+// WithFailureCodes sets the codes counted as failures. Defaults to DefaultFailureCodes.
+func WithFailureCodes(codes ...connect.Code) Option {
+	return func(o *options) {
+		o.failureCodes = codes
+	}
+}
+
+// WithWindow sets the rolling window the failure rate is computed over, and the number of buckets it is divided into.
+// Defaults to 10s divided into 10 buckets. It panics if there is no bucket, or if the window is too short to
+// be divided into that many buckets.
+func WithWindow(window time.Duration, buckets int) Option {
+	if buckets < 1 || window < time.Duration(buckets) {
+		panic(fmt.Sprintf("circuitbreaker: window %v can't be divided into %d buckets", window, buckets))
+	}
+	return func(o *options) {
+		o.window = window
+		o.buckets = buckets
+	}
+}
+
+// WithFailureRatio sets the ratio of failures over the rolling window above which the circuit opens. Defaults to 0.5.
+func WithFailureRatio(ratio float64) Option {
+	return func(o *options) {
+		o.failureRatio = ratio
+	}
+}
+
+// WithMinRequests sets the number of calls the rolling window must hold before the circuit may open. Defaults to 20.
+func WithMinRequests(n uint64) Option {
+	return func(o *options) {
+		o.minRequests = n
+	}
+}
+
+// WithOpenTimeout sets how long the circuit stays open before letting probe calls through. Defaults to 30s.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.openTimeout = d
+	}
+}
+
+// WithHalfOpenRequests sets the number of probe calls let through when half-open. The circuit closes once they
+// all succeeded, and opens again on the first failure. Defaults to 1.
+func WithHalfOpenRequests(n int) Option {
+	return func(o *options) {
+		o.halfOpenRequests = n
+	}
+}
+
+// WithLogger logs circuit state transitions to the given logger.
+func WithLogger(logger logging.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
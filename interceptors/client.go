@@ -0,0 +1,153 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+// Go gRPC Middleware monitoring interceptors for client-side gRPC.
+
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// UnaryClientInterceptor is a connect client-side interceptor that provides reporting for Unary RPCs.
+//
+// The request sent is reported to PostMsgSend as a connect.AnyResponse, and the response received to PostMsgReceive
+// as a connect.AnyRequest, whose Any method returns the message and whose headers are empty. The request itself is
+// available in CallMeta.ReqOrNil.
+func UnaryClientInterceptor(reportable ClientReportable) connect.UnaryInterceptorFunc {
+	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
+		return connect.UnaryFunc(func(
+			ctx context.Context,
+			req connect.AnyRequest,
+		) (connect.AnyResponse, error) {
+			r := newReport(NewClientCallMeta(req.Spec(), req))
+			reporter, newCtx := reportable.ClientReporter(ctx, r.callMeta)
+			headers := &unaryHeaders{req: req}
+			setHeaders(reporter, headers)
+
+			resp, err := next(newCtx, req)
+			if err == nil {
+				// Failed calls return a typed nil response.
+				headers.resp = resp
+			}
+			reporter.PostMsgSend(&messageResponse{msg: req.Any()}, err, time.Since(r.startTime))
+			if err == nil {
+				reporter.PostMsgReceive(&messageRequest{msg: resp.Any()}, nil, time.Since(r.startTime))
+			}
+			reporter.PostCall(err, time.Since(r.startTime))
+			return resp, err
+		})
+	}
+	return connect.UnaryInterceptorFunc(interceptor)
+}
+
+// StreamClientInterceptor is a connect client-side interceptor that provides reporting for Streaming RPCs.
+// Unary calls pass through, use UnaryClientInterceptor for them.
+//
+// Sent messages are reported to PostMsgSend as a connect.AnyResponse, and received messages to PostMsgReceive as a
+// connect.AnyRequest, whose Any method returns the message and whose headers are empty.
+//
+// The call is reported as finished when Receive first fails (io.EOF being the normal end of the stream), when
+// the response is closed, or when the context of the call is done. A stream abandoned without any of these, with
+// a context that is never canceled, is never reported as finished.
+func StreamClientInterceptor(reportable ClientReportable) connect.Interceptor {
+	return &streamClientInterceptor{reportable: reportable}
+}
+
+type streamClientInterceptor struct {
+	reportable ClientReportable
+}
+
+func (i *streamClientInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return next
+}
+
+func (i *streamClientInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return connect.StreamingClientFunc(func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		r := newReport(NewClientCallMeta(spec, nil))
+		reporter, newCtx := i.reportable.ClientReporter(ctx, r.callMeta)
+
+		conn := next(newCtx, spec)
+		s := &monitoredClientStream{
+			StreamingClientConn: conn,
+			startTime:           r.startTime,
+			reporter:            reporter,
+			headers:             &clientStreamHeaders{conn: conn},
+		}
+		setHeaders(reporter, s.headers)
+		// Streams abandoned without being closed are finished once their context is done.
+		s.stop = context.AfterFunc(ctx, func() {
+			s.finish(contextErrToConnectErr(ctx.Err()))
+		})
+		return s
+	})
+}
+
+func (i *streamClientInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// monitoredClientStream wraps connect.StreamingClientConn allowing each Send/Receive call to be monitored.
+type monitoredClientStream struct {
+	connect.StreamingClientConn
+
+	startTime time.Time
+	reporter  Reporter
+	headers   *clientStreamHeaders
+	finished  sync.Once
+	// stop stops finishing the stream when its context is done.
+	stop func() bool
+}
+
+func (s *monitoredClientStream) Send(m any) error {
+	start := time.Now()
+	err := s.StreamingClientConn.Send(m)
+	s.reporter.PostMsgSend(&messageResponse{msg: m}, err, time.Since(start))
+	return err
+}
+
+func (s *monitoredClientStream) Receive(m any) error {
+	start := time.Now()
+	err := s.StreamingClientConn.Receive(m)
+	if err == nil {
+		s.reporter.PostMsgReceive(&messageRequest{msg: m}, nil, time.Since(start))
+		return nil
+	}
+	if !errors.Is(err, io.EOF) {
+		s.reporter.PostMsgReceive(nil, err, time.Since(start))
+	}
+	s.stop()
+	s.headers.responded.Store(true)
+	s.finish(err)
+	return err
+}
+
+func (s *monitoredClientStream) CloseResponse() error {
+	err := s.StreamingClientConn.CloseResponse()
+	s.stop()
+	s.headers.responded.Store(true)
+	s.finish(err)
+	return err
+}
+
+func (s *monitoredClientStream) finish(err error) {
+	s.finished.Do(func() {
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		s.reporter.PostCall(err, time.Since(s.startTime))
+	})
+}
+
+func contextErrToConnectErr(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return connect.NewError(connect.CodeDeadlineExceeded, err)
+	}
+	return connect.NewError(connect.CodeCanceled, err)
+}
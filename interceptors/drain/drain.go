@@ -0,0 +1,172 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package drain
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// ErrDraining is the cause of the connect.CodeUnavailable error returned for calls rejected while draining.
+var ErrDraining = errors.New("server is draining")
+
+var _ connect.Interceptor = &Controller{}
+
+// Controller is a server interceptor tracking in-flight unary and streaming calls, to support zero-downtime
+// deploys. Once Drain is called, new calls are rejected with connect.CodeUnavailable and a Retry-After hint,
+// and Wait blocks until the calls in flight finished.
+//
+// Client calls pass through.
+type Controller struct {
+	opts *options
+
+	mu       sync.Mutex
+	inFlight int64
+	rejected uint64
+	draining bool
+	// done is closed once draining and no call is in flight anymore.
+	done    chan struct{}
+	streams map[*context.CancelFunc]struct{}
+}
+
+// NewController returns a new Controller.
+func NewController(opts ...Option) *Controller {
+	return &Controller{
+		opts:    evaluateOptions(opts),
+		done:    make(chan struct{}),
+		streams: map[*context.CancelFunc]struct{}{},
+	}
+}
+
+// InFlight returns the number of calls in flight, e.g. to be exported as a gauge.
+func (c *Controller) InFlight() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inFlight
+}
+
+// Rejected returns the number of calls rejected because the controller was draining.
+func (c *Controller) Rejected() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rejected
+}
+
+// Draining reports whether Drain was called.
+func (c *Controller) Draining() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.draining
+}
+
+// Drain stops accepting new calls. Calls in flight are left to finish, except streams once the grace period
+// set with WithStreamGracePeriod elapsed. It is safe to call Drain several times.
+func (c *Controller) Drain() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.draining {
+		return
+	}
+	c.draining = true
+	if c.inFlight == 0 {
+		close(c.done)
+	}
+	if c.opts.streamGracePeriod > 0 {
+		time.AfterFunc(c.opts.streamGracePeriod, c.cancelStreams)
+	}
+}
+
+// Wait drains the controller if it isn't yet, and blocks until no call is in flight anymore or the context is done.
+func (c *Controller) Wait(ctx context.Context) error {
+	c.Drain()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Controller) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return connect.UnaryFunc(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		if !c.begin(nil) {
+			return nil, c.drainingError()
+		}
+		defer c.end(nil)
+		return next(ctx, req)
+	})
+}
+
+func (c *Controller) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (c *Controller) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return connect.StreamingHandlerFunc(func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		if !c.begin(&cancel) {
+			return c.drainingError()
+		}
+		defer c.end(&cancel)
+		return next(ctx, conn)
+	})
+}
+
+// begin registers a new call in flight, and its cancel function for streams. It returns false if draining.
+func (c *Controller) begin(cancel *context.CancelFunc) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.draining {
+		c.rejected++
+		return false
+	}
+	c.inFlight++
+	if cancel != nil {
+		c.streams[cancel] = struct{}{}
+	}
+	return true
+}
+
+func (c *Controller) end(cancel *context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inFlight--
+	if cancel != nil {
+		delete(c.streams, cancel)
+	}
+	if c.draining && c.inFlight == 0 {
+		close(c.done)
+	}
+}
+
+func (c *Controller) cancelStreams() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for cancel := range c.streams {
+		(*cancel)()
+	}
+}
+
+func (c *Controller) drainingError() error {
+	err := connect.NewError(connect.CodeUnavailable, ErrDraining)
+	if c.opts.retryAfter > 0 {
+		seconds := int((c.opts.retryAfter + time.Second - 1) / time.Second)
+		err.Meta().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	return err
+}
@@ -0,0 +1,204 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package drain_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/drain"
+)
+
+const (
+	blockProcedure = "/test.v1.TestService/Block"
+	watchProcedure = "/test.v1.TestService/Watch"
+)
+
+type testServer struct {
+	block, watch *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue]
+	// started receives a value once a handler is called.
+	started chan struct{}
+	// release unblocks the Block handlers once closed.
+	release chan struct{}
+	// watchErr receives the context error of the Watch handlers.
+	watchErr chan error
+}
+
+// newServer returns a server drained by c, of a Block unary procedure blocking until released and of a Watch server
+// stream procedure blocking until its context is done.
+func newServer(t *testing.T, c *drain.Controller) *testServer {
+	s := &testServer{
+		started:  make(chan struct{}, 1),
+		release:  make(chan struct{}),
+		watchErr: make(chan error, 1),
+	}
+	mux := http.NewServeMux()
+	mux.Handle(blockProcedure, connect.NewUnaryHandler(blockProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		s.started <- struct{}{}
+		<-s.release
+		return connect.NewResponse(req.Msg), nil
+	}, connect.WithInterceptors(c)))
+	mux.Handle(watchProcedure, connect.NewServerStreamHandler(watchProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+		s.started <- struct{}{}
+		<-ctx.Done()
+		s.watchErr <- ctx.Err()
+		return ctx.Err()
+	}, connect.WithInterceptors(c)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() {
+		select {
+		case <-s.release:
+		default:
+			close(s.release)
+		}
+	})
+
+	s.block = connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+blockProcedure)
+	s.watch = connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+watchProcedure)
+	return s
+}
+
+func TestDrain_Rejects(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		opts       []drain.Option
+		retryAfter string
+	}{
+		{name: "default", retryAfter: "1"},
+		{name: "rounded up", opts: []drain.Option{drain.WithRetryAfter(1500 * time.Millisecond)}, retryAfter: "2"},
+		{name: "disabled", opts: []drain.Option{drain.WithRetryAfter(0)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := drain.NewController(tc.opts...)
+			s := newServer(t, c)
+			c.Drain()
+			if !c.Draining() {
+				t.Fatal("not draining after Drain")
+			}
+
+			_, err := s.block.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("hi")))
+			var connectErr *connect.Error
+			if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeUnavailable {
+				t.Fatalf("got %v, want unavailable", err)
+			}
+			if got := connectErr.Meta().Get("Retry-After"); got != tc.retryAfter {
+				t.Fatalf("Retry-After = %q, want %q", got, tc.retryAfter)
+			}
+
+			stream, err := s.watch.CallServerStream(context.Background(), connect.NewRequest(wrapperspb.String("hi")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stream.Receive() || connect.CodeOf(stream.Err()) != connect.CodeUnavailable {
+				t.Fatalf("got %v, want unavailable", stream.Err())
+			}
+			_ = stream.Close()
+
+			if got := c.Rejected(); got != 2 {
+				t.Fatalf("rejected %d calls, want 2", got)
+			}
+		})
+	}
+}
+
+func TestDrain_WaitInFlight(t *testing.T) {
+	c := drain.NewController()
+	s := newServer(t, c)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.block.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("hi")))
+		done <- err
+	}()
+	<-s.started
+	if got := c.InFlight(); got != 1 {
+		t.Fatalf("%d calls in flight, want 1", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait returned %v with a call in flight, want the context error", err)
+	}
+	if !c.Draining() {
+		t.Fatal("not draining after Wait")
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- c.Wait(context.Background()) }()
+	select {
+	case err := <-waited:
+		t.Fatalf("Wait returned %v with a call in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(s.release)
+	if err := <-waited; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("the call in flight failed: %v", err)
+	}
+	if got := c.InFlight(); got != 0 {
+		t.Fatalf("%d calls in flight, want 0", got)
+	}
+}
+
+func TestDrain_StreamGracePeriod(t *testing.T) {
+	c := drain.NewController(drain.WithStreamGracePeriod(50 * time.Millisecond))
+	s := newServer(t, c)
+
+	stream, err := s.watch.CallServerStream(context.Background(), connect.NewRequest(wrapperspb.String("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = stream.Close() })
+	<-s.started
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("stream canceled after %v, before the grace period", elapsed)
+	}
+	if err := <-s.watchErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("stream context error = %v, want canceled", err)
+	}
+}
+
+func TestDrain_StreamWithoutGracePeriod(t *testing.T) {
+	c := drain.NewController()
+	s := newServer(t, c)
+
+	ctx, cancelStream := context.WithCancel(context.Background())
+	stream, err := s.watch.CallServerStream(ctx, connect.NewRequest(wrapperspb.String("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = stream.Close() })
+	<-s.started
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := c.Wait(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait returned %v, want the stream left running", err)
+	}
+
+	// The stream finishes when the client cancels it.
+	cancelStream()
+	if err := c.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
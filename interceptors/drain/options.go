@@ -0,0 +1,48 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package drain
+
+import (
+	"time"
+)
+
+var (
+	defaultOptions = &options{
+		retryAfter:        time.Second,
+		streamGracePeriod: 0, // disabled
+	}
+)
+
+type options struct {
+	retryAfter        time.Duration
+	streamGracePeriod time.Duration
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithRetryAfter sets the retry hint sent in the Retry-After header (in seconds, rounded up) of calls rejected
+// while draining. Zero disables the header. Defaults to 1s.
+func WithRetryAfter(d time.Duration) Option {
+	return func(o *options) {
+		o.retryAfter = d
+	}
+}
+
+// WithStreamGracePeriod cancels the context of streaming calls still in flight once the given period elapsed
+// after Drain was called, so that long-lived streams can't hold a shutdown forever. Zero (the default) never
+// cancels streams.
+func WithStreamGracePeriod(d time.Duration) Option {
+	return func(o *options) {
+		o.streamGracePeriod = d
+	}
+}
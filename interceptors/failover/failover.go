@@ -0,0 +1,185 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// NewClientFunc creates the client of one endpoint, typically a closure around a generated New...Client function.
+// The given options must be passed on to the client, failover relies on them to learn about the calls.
+type NewClientFunc[T any] func(baseURL string, opts ...connect.ClientOption) T
+
+// Failover holds one client per base URL, the first one being the primary. Calls made through Call go to the
+// first healthy endpoint, and idempotent unary calls failing with one of the failover codes (Unavailable by
+// default, which covers connection-level failures) are sent to the next healthy endpoint.
+//
+// Endpoints failing several times in a row are ejected for an exponentially growing period of time.
+type Failover[T any] struct {
+	opts      *options
+	endpoints []*endpoint[T]
+}
+
+// EndpointStatus describes an endpoint, e.g. to be shown on a health page.
+type EndpointStatus struct {
+	BaseURL string
+	Healthy bool
+	// EjectedUntil is set while the endpoint is ejected.
+	EjectedUntil time.Time
+}
+
+// New returns a new Failover creating one client per base URL with newClient. It panics if there is no base URL.
+func New[T any](newClient NewClientFunc[T], baseURLs []string, opts ...Option) *Failover[T] {
+	if len(baseURLs) == 0 {
+		panic("failover: no base URL")
+	}
+	o := evaluateOptions(opts)
+	clientOpts := append([]connect.ClientOption{connect.WithInterceptors(recordSpec)}, o.clientOptions...)
+
+	f := &Failover[T]{opts: o}
+	for _, u := range baseURLs {
+		f.endpoints = append(f.endpoints, &endpoint[T]{baseURL: u, client: newClient(u, clientOpts...)})
+	}
+	return f
+}
+
+// Endpoints returns the status of every endpoint, in order.
+func (f *Failover[T]) Endpoints() []EndpointStatus {
+	now := time.Now()
+	statuses := make([]EndpointStatus, 0, len(f.endpoints))
+	for _, e := range f.endpoints {
+		e.mu.Lock()
+		s := EndpointStatus{BaseURL: e.baseURL, Healthy: !now.Before(e.ejectedUntil)}
+		if !s.Healthy {
+			s.EjectedUntil = e.ejectedUntil
+		}
+		e.mu.Unlock()
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// Call invokes call with the client of the first healthy endpoint, and fails over to the next healthy endpoints
+// as long as the call is an idempotent unary call failing with one of the failover codes. When every endpoint is
+// ejected, all of them are tried in order. A Failover without endpoints fails calls with connect.CodeUnavailable.
+//
+// The call function must make a single call with the client it is given, e.g.:
+//
+//	res, err := failover.Call(ctx, f, func(ctx context.Context, c pingv1connect.PingServiceClient) (*connect.Response[pingv1.PingResponse], error) {
+//		return c.Ping(ctx, req)
+//	})
+func Call[T, Res any](ctx context.Context, f *Failover[T], call func(context.Context, T) (Res, error)) (Res, error) {
+	var (
+		res Res
+		err error
+	)
+	if len(f.endpoints) == 0 {
+		return res, connect.NewError(connect.CodeUnavailable, errors.New("failover: no endpoint"))
+	}
+	for _, e := range f.candidates() {
+		state := &callState{}
+		res, err = call(context.WithValue(ctx, callStateKey, state), e.client)
+		failed := err != nil && f.isFailoverCode(err)
+		e.report(failed, f.opts)
+		if !failed || !state.canFailover() || ctx.Err() != nil {
+			return res, err
+		}
+	}
+	return res, err
+}
+
+func (f *Failover[T]) isFailoverCode(err error) bool {
+	code := connect.CodeOf(err)
+	for _, c := range f.opts.codes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Failover[T]) candidates() []*endpoint[T] {
+	now := time.Now()
+	healthy := make([]*endpoint[T], 0, len(f.endpoints))
+	for _, e := range f.endpoints {
+		if e.healthy(now) {
+			healthy = append(healthy, e)
+		}
+	}
+	if len(healthy) == 0 {
+		return f.endpoints
+	}
+	return healthy
+}
+
+type endpoint[T any] struct {
+	baseURL string
+	client  T
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	ejections           int
+	ejectedUntil        time.Time
+}
+
+func (e *endpoint[T]) healthy(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !now.Before(e.ejectedUntil)
+}
+
+// report records the outcome of a call, ejecting the endpoint after too many consecutive failures.
+func (e *endpoint[T]) report(failed bool, o *options) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !failed {
+		e.consecutiveFailures = 0
+		e.ejections = 0
+		return
+	}
+	e.consecutiveFailures++
+	if e.consecutiveFailures < o.consecutiveFailures {
+		return
+	}
+	ejection := o.baseEjectionTime
+	for i := 0; i < e.ejections && ejection < o.maxEjectionTime; i++ {
+		ejection *= 2
+	}
+	if ejection > o.maxEjectionTime {
+		ejection = o.maxEjectionTime
+	}
+	e.ejections++
+	e.consecutiveFailures = 0
+	e.ejectedUntil = time.Now().Add(ejection)
+}
+
+type callStateMarker struct{}
+
+var callStateKey = &callStateMarker{}
+
+// callState is filled by the recordSpec interceptor of the endpoint clients, so that Call learns about the
+// spec of the call made by the user function.
+type callState struct {
+	spec connect.Spec
+	seen bool
+}
+
+func (s *callState) canFailover() bool {
+	return s.seen && s.spec.StreamType == connect.StreamTypeUnary && s.spec.IdempotencyLevel != connect.IdempotencyUnknown
+}
+
+var recordSpec = connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+	return connect.UnaryFunc(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if state, ok := ctx.Value(callStateKey).(*callState); ok {
+			state.spec, state.seen = req.Spec(), true
+		}
+		return next(ctx, req)
+	})
+})
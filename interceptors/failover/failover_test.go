@@ -0,0 +1,165 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package failover_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/failover"
+)
+
+const procedure = "/test.v1.TestService/Echo"
+
+type client = *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue]
+
+type server struct {
+	*httptest.Server
+	calls int32
+}
+
+func newServer(t *testing.T, name string, code connect.Code) *server {
+	s := &server{}
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		atomic.AddInt32(&s.calls, 1)
+		if code != 0 {
+			return nil, connect.NewError(code, errors.New(name+" failed"))
+		}
+		return connect.NewResponse(wrapperspb.String(name)), nil
+	}))
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newFailover(idempotency connect.IdempotencyLevel, urls []string, opts ...failover.Option) *failover.Failover[client] {
+	return failover.New(func(baseURL string, opts ...connect.ClientOption) client {
+		opts = append(opts, connect.WithIdempotency(idempotency))
+		return connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](http.DefaultClient, baseURL+procedure, opts...)
+	}, urls, opts...)
+}
+
+func echo(ctx context.Context, f *failover.Failover[client]) (string, error) {
+	res, err := failover.Call(ctx, f, func(ctx context.Context, c client) (*connect.Response[wrapperspb.StringValue], error) {
+		return c.CallUnary(ctx, connect.NewRequest(wrapperspb.String("ping")))
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.Msg.Value, nil
+}
+
+func TestCall_FailsOverIdempotentCalls(t *testing.T) {
+	primary := newServer(t, "primary", connect.CodeUnavailable)
+	secondary := newServer(t, "secondary", 0)
+
+	f := newFailover(connect.IdempotencyNoSideEffects, []string{primary.URL, secondary.URL}, failover.WithConsecutiveFailures(2))
+	for i := 0; i < 4; i++ {
+		got, err := echo(context.Background(), f)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "secondary" {
+			t.Fatalf("expected response from secondary, got %q", got)
+		}
+	}
+	// The primary is ejected after two consecutive failures, so it only sees the first two calls.
+	if calls := atomic.LoadInt32(&primary.calls); calls != 2 {
+		t.Fatalf("expected 2 calls to the primary, got %d", calls)
+	}
+	if status := f.Endpoints(); status[0].Healthy || !status[1].Healthy {
+		t.Fatalf("expected primary to be ejected, got %+v", status)
+	}
+}
+
+func TestCall_DoesNotFailOverNonIdempotentCalls(t *testing.T) {
+	primary := newServer(t, "primary", connect.CodeUnavailable)
+	secondary := newServer(t, "secondary", 0)
+
+	f := newFailover(connect.IdempotencyUnknown, []string{primary.URL, secondary.URL})
+	_, err := echo(context.Background(), f)
+	if connect.CodeOf(err) != connect.CodeUnavailable {
+		t.Fatalf("expected unavailable error, got %v", err)
+	}
+	if calls := atomic.LoadInt32(&secondary.calls); calls != 0 {
+		t.Fatalf("expected no call to the secondary, got %d", calls)
+	}
+}
+
+func TestCall_DoesNotFailOverOtherCodes(t *testing.T) {
+	primary := newServer(t, "primary", connect.CodeInvalidArgument)
+	secondary := newServer(t, "secondary", 0)
+
+	f := newFailover(connect.IdempotencyNoSideEffects, []string{primary.URL, secondary.URL})
+	_, err := echo(context.Background(), f)
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+}
+
+func TestCall_ConnectionFailure(t *testing.T) {
+	down := newServer(t, "down", 0)
+	down.Close()
+	secondary := newServer(t, "secondary", 0)
+
+	f := newFailover(connect.IdempotencyIdempotent, []string{down.URL, secondary.URL})
+	got, err := echo(context.Background(), f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "secondary" {
+		t.Fatalf("expected response from secondary, got %q", got)
+	}
+}
+
+func TestCall_EjectionBackoff(t *testing.T) {
+	primary := newServer(t, "primary", connect.CodeUnavailable)
+	secondary := newServer(t, "secondary", 0)
+
+	f := newFailover(connect.IdempotencyNoSideEffects, []string{primary.URL, secondary.URL},
+		failover.WithConsecutiveFailures(1), failover.WithEjectionTime(20*time.Millisecond, time.Second))
+
+	if _, err := echo(context.Background(), f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := time.Until(f.Endpoints()[0].EjectedUntil)
+
+	time.Sleep(25 * time.Millisecond)
+	if !f.Endpoints()[0].Healthy {
+		t.Fatal("expected primary to be back after its ejection time")
+	}
+	if _, err := echo(context.Background(), f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Failing again right after coming back doubles the ejection time.
+	second := time.Until(f.Endpoints()[0].EjectedUntil)
+	if second <= first {
+		t.Fatalf("expected ejection time to grow, got %v then %v", first, second)
+	}
+}
+
+func TestNew_NoEndpoint(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("want a panic without base URL")
+		}
+	}()
+	newFailover(connect.IdempotencyNoSideEffects, nil)
+}
+
+func TestCall_NoEndpoint(t *testing.T) {
+	var f failover.Failover[client]
+	if _, err := echo(context.Background(), &f); connect.CodeOf(err) != connect.CodeUnavailable {
+		t.Fatalf("got %v, want an unavailable error", err)
+	}
+}
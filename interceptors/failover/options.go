@@ -0,0 +1,72 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package failover
+
+import (
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+var (
+	// DefaultFailoverCodes are the codes a call is sent to the next endpoint on. Connection-level failures are
+	// reported by connect clients as connect.CodeUnavailable.
+	DefaultFailoverCodes = []connect.Code{connect.CodeUnavailable}
+
+	defaultOptions = &options{
+		codes:               DefaultFailoverCodes,
+		consecutiveFailures: 5,
+		baseEjectionTime:    30 * time.Second,
+		maxEjectionTime:     5 * time.Minute,
+	}
+)
+
+type options struct {
+	codes               []connect.Code
+	consecutiveFailures int
+	baseEjectionTime    time.Duration
+	maxEjectionTime     time.Duration
+	clientOptions       []connect.ClientOption
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithCodes sets the codes a call is sent to the next endpoint on. Defaults to DefaultFailoverCodes.
+func WithCodes(codes ...connect.Code) Option {
+	return func(o *options) {
+		o.codes = codes
+	}
+}
+
+// WithConsecutiveFailures sets the number of consecutive failures after which an endpoint is ejected. Defaults to 5.
+func WithConsecutiveFailures(n int) Option {
+	return func(o *options) {
+		o.consecutiveFailures = n
+	}
+}
+
+// WithEjectionTime sets for how long an endpoint is ejected. The ejection time doubles every time the endpoint
+// is ejected again without a successful call in between, up to max. Defaults to 30s and 5m.
+func WithEjectionTime(base, max time.Duration) Option {
+	return func(o *options) {
+		o.baseEjectionTime = base
+		o.maxEjectionTime = max
+	}
+}
+
+// WithClientOptions sets connect.ClientOptions passed to the client of every endpoint.
+func WithClientOptions(opts ...connect.ClientOption) Option {
+	return func(o *options) {
+		o.clientOptions = opts
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package interceptors
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"connectrpc.com/connect"
+)
+
+// Headers gives access to the headers and trailers of a call. The response headers and trailers are nil until the
+// response is known, and only complete once the call is finished: when PostCall is called.
+type Headers interface {
+	RequestHeader() http.Header
+	ResponseHeader() http.Header
+	ResponseTrailer() http.Header
+}
+
+// HeadersReporter is optionally implemented by Reporters wanting the headers and trailers of calls, which messages
+// don't carry for streams. SetHeaders is called before any other method of the Reporter.
+type HeadersReporter interface {
+	SetHeaders(Headers)
+}
+
+// setHeaders passes the headers to the reporter, if it wants them.
+func setHeaders(reporter Reporter, h Headers) {
+	if hr, ok := reporter.(HeadersReporter); ok {
+		hr.SetHeaders(h)
+	}
+}
+
+// unaryHeaders are the Headers of unary calls, whose response is set once returned.
+type unaryHeaders struct {
+	req  connect.AnyRequest
+	resp connect.AnyResponse
+}
+
+func (h *unaryHeaders) RequestHeader() http.Header {
+	return h.req.Header()
+}
+
+func (h *unaryHeaders) ResponseHeader() http.Header {
+	if h.resp == nil {
+		return nil
+	}
+	return h.resp.Header()
+}
+
+func (h *unaryHeaders) ResponseTrailer() http.Header {
+	if h.resp == nil {
+		return nil
+	}
+	return h.resp.Trailer()
+}
+
+// clientStreamHeaders are the Headers of client streams. The response headers of the connection block until the
+// response is received, and must not be read concurrently with Receive: they are only read once the stream was
+// finished by Receive or CloseResponse.
+type clientStreamHeaders struct {
+	conn      connect.StreamingClientConn
+	responded atomic.Bool
+}
+
+func (h *clientStreamHeaders) RequestHeader() http.Header {
+	return h.conn.RequestHeader()
+}
+
+func (h *clientStreamHeaders) ResponseHeader() http.Header {
+	if !h.responded.Load() {
+		return nil
+	}
+	return h.conn.ResponseHeader()
+}
+
+func (h *clientStreamHeaders) ResponseTrailer() http.Header {
+	if !h.responded.Load() {
+		return nil
+	}
+	return h.conn.ResponseTrailer()
+}
@@ -0,0 +1,211 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package hedging
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// recomputeEvery is the number of observed latencies after which the percentile delay is recomputed.
+const recomputeEvery = 64
+
+// Hedger sends hedged copies of idempotent unary requests to cut tail latency, e.g. when a replica is slow.
+//
+// If the original request hasn't been answered within the hedging delay, a copy of it is sent. The first
+// successful response wins and the other attempts are canceled. An error is only returned once all the
+// attempts failed, hedging does not replace retries.
+//
+// Only calls whose Spec.IdempotencyLevel is not connect.IdempotencyUnknown are hedged, the others pass through.
+type Hedger struct {
+	opts *options
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	observed  int
+	delay     time.Duration
+
+	calls  uint64
+	hedged uint64
+	won    uint64
+}
+
+// Stats is a snapshot of the hedging counters.
+type Stats struct {
+	// Calls is the number of hedge-eligible calls.
+	Calls uint64
+	// Hedged is the number of calls for which at least one hedged copy was sent.
+	Hedged uint64
+	// HedgeWins is the number of calls answered by a hedged copy rather than by the original request.
+	HedgeWins uint64
+	// Delay is the current hedging delay.
+	Delay time.Duration
+}
+
+// New returns a new Hedger.
+func New(opts ...Option) *Hedger {
+	o := evaluateOptions(opts)
+	return &Hedger{
+		opts:      o,
+		latencies: make([]time.Duration, o.window),
+		delay:     o.delay,
+	}
+}
+
+// Stats returns a snapshot of the hedging counters.
+func (h *Hedger) Stats() Stats {
+	return Stats{
+		Calls:     atomic.LoadUint64(&h.calls),
+		Hedged:    atomic.LoadUint64(&h.hedged),
+		HedgeWins: atomic.LoadUint64(&h.won),
+		Delay:     h.currentDelay(),
+	}
+}
+
+type result struct {
+	resp    connect.AnyResponse
+	err     error
+	attempt int
+}
+
+// hedgeKey is the context key of the hedgeFunc set by Unary.
+type hedgeKey struct{}
+
+// hedgeFunc sends an attempt of the current call. In the context, a nil hedgeFunc marks a hedged copy.
+type hedgeFunc func(ctx context.Context) (connect.AnyResponse, error)
+
+// Unary wraps a unary client method, e.g. client.CallUnary or a method of a generated client, so that its calls
+// can be hedged by the Hedger's UnaryClientInterceptor.
+//
+// Hedged copies are made of a new *connect.Request holding the same message and a copy of the headers of the
+// original request, and are sent through the whole client: every interceptor of the client chain sees them with
+// their own spec and peer.
+func Unary[Req, Res any](call func(context.Context, *connect.Request[Req]) (*connect.Response[Res], error)) func(context.Context, *connect.Request[Req]) (*connect.Response[Res], error) {
+	return func(ctx context.Context, req *connect.Request[Req]) (*connect.Response[Res], error) {
+		// Headers have to be copied before the original request is sent, as sending it writes to them.
+		header := req.Header().Clone()
+		send := hedgeFunc(func(ctx context.Context) (connect.AnyResponse, error) {
+			c := connect.NewRequest(req.Msg)
+			for k, v := range header {
+				c.Header()[k] = append([]string(nil), v...)
+			}
+			resp, err := call(context.WithValue(ctx, hedgeKey{}, hedgeFunc(nil)), c)
+			if resp == nil {
+				return nil, err
+			}
+			return resp, err
+		})
+		return call(context.WithValue(ctx, hedgeKey{}, send), req)
+	}
+}
+
+// UnaryClientInterceptor returns a new unary client interceptor hedging idempotent calls.
+//
+// Only calls made through a method wrapped with Unary are hedged, the others pass through. For instance:
+//
+//	h := hedging.New(hedging.WithPercentileDelay(0.95, 1000))
+//	client := connect.NewClient[Req, Res](httpClient, url, connect.WithInterceptors(h.UnaryClientInterceptor()))
+//	resp, err := hedging.Unary(client.CallUnary)(ctx, req)
+func (h *Hedger) UnaryClientInterceptor() connect.UnaryInterceptorFunc {
+	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
+		return connect.UnaryFunc(func(
+			ctx context.Context,
+			req connect.AnyRequest,
+		) (connect.AnyResponse, error) {
+			send, _ := ctx.Value(hedgeKey{}).(hedgeFunc)
+			if send == nil || !req.Spec().IsClient || req.Spec().IdempotencyLevel == connect.IdempotencyUnknown || h.opts.maxHedges <= 0 {
+				return next(ctx, req)
+			}
+			atomic.AddUint64(&h.calls, 1)
+			return h.hedge(ctx, func(ctx context.Context) (connect.AnyResponse, error) { return next(ctx, req) }, send)
+		})
+	}
+	return connect.UnaryInterceptorFunc(interceptor)
+}
+
+// hedge sends the original request, then up to maxHedges copies of it, and returns the first successful response.
+func (h *Hedger) hedge(ctx context.Context, original, hedged hedgeFunc) (connect.AnyResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	results := make(chan result, h.opts.maxHedges+1)
+	send := func(attempt int, call hedgeFunc) {
+		go func() {
+			resp, err := call(ctx)
+			results <- result{resp: resp, err: err, attempt: attempt}
+		}()
+	}
+
+	send(0, original)
+	sent, pending := 1, 1
+	timer := time.NewTimer(h.currentDelay())
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-timer.C:
+			if sent == 1 {
+				atomic.AddUint64(&h.hedged, 1)
+			}
+			send(sent, hedged)
+			sent++
+			pending++
+			if sent <= h.opts.maxHedges {
+				timer.Reset(h.currentDelay())
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				if res.attempt > 0 {
+					atomic.AddUint64(&h.won, 1)
+				}
+				h.observe(time.Since(start))
+				return res.resp, nil
+			}
+			lastErr = res.err
+			if pending == 0 {
+				return nil, lastErr
+			}
+		}
+	}
+}
+
+func (h *Hedger) currentDelay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.delay
+}
+
+// observe records the latency of a successful call and recomputes the percentile delay every recomputeEvery calls.
+func (h *Hedger) observe(d time.Duration) {
+	if h.opts.window <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.latencies[h.next] = d
+	h.next = (h.next + 1) % len(h.latencies)
+	h.observed++
+	if h.observed < h.opts.minSamples || (h.observed-h.opts.minSamples)%recomputeEvery != 0 {
+		return
+	}
+
+	n := h.observed
+	if n > len(h.latencies) {
+		n = len(h.latencies)
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, h.latencies[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	h.delay = sorted[int(float64(n-1)*h.opts.percentile)]
+}
@@ -0,0 +1,214 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package hedging_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/hedging"
+)
+
+const procedure = "/test.v1.TestService/Echo"
+
+type client = *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue]
+
+// newServer returns a server answering with the "x-attempt" header it got. The first call is answered after
+// firstDelay, or fails if fail is set.
+func newServer(t *testing.T, firstDelay time.Duration, fail bool, calls *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		if atomic.AddInt32(calls, 1) == 1 {
+			select {
+			case <-time.After(firstDelay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if fail {
+			return nil, connect.NewError(connect.CodeUnavailable, errors.New("failed"))
+		}
+		return connect.NewResponse(wrapperspb.String(req.Msg.Value + " " + req.Header().Get("X-Test"))), nil
+	}, connect.WithIdempotency(connect.IdempotencyNoSideEffects)))
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// specRecorder records the spec of every request going through the client chain after the hedger.
+type specRecorder struct {
+	mu    sync.Mutex
+	specs []connect.Spec
+}
+
+func (r *specRecorder) interceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			r.mu.Lock()
+			r.specs = append(r.specs, req.Spec())
+			r.mu.Unlock()
+			return next(ctx, req)
+		}
+	}
+}
+
+func newClient(url string, idempotency connect.IdempotencyLevel, interceptors ...connect.Interceptor) client {
+	return connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		http.DefaultClient, url+procedure,
+		connect.WithIdempotency(idempotency),
+		connect.WithInterceptors(interceptors...),
+	)
+}
+
+func newRequest() *connect.Request[wrapperspb.StringValue] {
+	req := connect.NewRequest(wrapperspb.String("hi"))
+	req.Header().Set("X-Test", "header")
+	return req
+}
+
+func TestHedgedCopyWins(t *testing.T) {
+	var calls int32
+	s := newServer(t, time.Second, false, &calls)
+	h := hedging.New(hedging.WithDelay(10 * time.Millisecond))
+	rec := &specRecorder{}
+	c := newClient(s.URL, connect.IdempotencyNoSideEffects, h.UnaryClientInterceptor(), rec.interceptor())
+
+	start := time.Now()
+	resp, err := hedging.Unary(c.CallUnary)(context.Background(), newRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("call took %v, want the hedged copy to answer before the slow original", elapsed)
+	}
+	if resp.Msg.Value != "hi header" {
+		t.Fatalf("got %q, want the message and headers of the original request", resp.Msg.Value)
+	}
+	if got := h.Stats(); got.Calls != 1 || got.Hedged != 1 || got.HedgeWins != 1 {
+		t.Fatalf("stats = %+v", got)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.specs) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(rec.specs))
+	}
+	for _, spec := range rec.specs {
+		if spec.Procedure != procedure || !spec.IsClient || spec.IdempotencyLevel != connect.IdempotencyNoSideEffects {
+			t.Fatalf("attempt spec = %+v, want the spec of the call", spec)
+		}
+	}
+}
+
+func TestFastOriginalNotHedged(t *testing.T) {
+	var calls int32
+	s := newServer(t, 0, false, &calls)
+	h := hedging.New(hedging.WithDelay(time.Second))
+	c := newClient(s.URL, connect.IdempotencyNoSideEffects, h.UnaryClientInterceptor())
+
+	if _, err := hedging.Unary(c.CallUnary)(context.Background(), newRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Stats(); got.Calls != 1 || got.Hedged != 0 || got.HedgeWins != 0 || calls != 1 {
+		t.Fatalf("stats = %+v, calls = %d", got, calls)
+	}
+}
+
+func TestNotHedged(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		idempotency connect.IdempotencyLevel
+		wrapped     bool
+	}{
+		{name: "idempotency unknown", idempotency: connect.IdempotencyUnknown, wrapped: true},
+		{name: "not wrapped with Unary", idempotency: connect.IdempotencyNoSideEffects},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			s := newServer(t, 50*time.Millisecond, false, &calls)
+			h := hedging.New(hedging.WithDelay(time.Millisecond))
+			c := newClient(s.URL, tc.idempotency, h.UnaryClientInterceptor())
+
+			call := c.CallUnary
+			if tc.wrapped {
+				call = hedging.Unary(call)
+			}
+			if _, err := call(context.Background(), newRequest()); err != nil {
+				t.Fatal(err)
+			}
+			if got := h.Stats(); got.Calls != 0 || calls != 1 {
+				t.Fatalf("stats = %+v, calls = %d, want the call to pass through", got, calls)
+			}
+		})
+	}
+}
+
+func TestAllAttemptsFail(t *testing.T) {
+	var calls int32
+	s := newServer(t, 20*time.Millisecond, true, &calls)
+	h := hedging.New(hedging.WithDelay(time.Millisecond), hedging.WithMaxHedges(2))
+	c := newClient(s.URL, connect.IdempotencyNoSideEffects, h.UnaryClientInterceptor())
+
+	_, err := hedging.Unary(c.CallUnary)(context.Background(), newRequest())
+	if connect.CodeOf(err) != connect.CodeUnavailable {
+		t.Fatalf("err = %v, want the error of the attempts", err)
+	}
+	if calls != 3 {
+		t.Fatalf("server got %d calls, want the original and 2 hedged copies", calls)
+	}
+	if got := h.Stats(); got.HedgeWins != 0 {
+		t.Fatalf("stats = %+v", got)
+	}
+}
+
+func TestPercentileDelay(t *testing.T) {
+	var calls int32
+	s := newServer(t, 0, false, &calls)
+	h := hedging.New(hedging.WithDelay(time.Hour), hedging.WithPercentileDelay(0.5, 10))
+	c := newClient(s.URL, connect.IdempotencyNoSideEffects, h.UnaryClientInterceptor())
+
+	if _, err := hedging.Unary(c.CallUnary)(context.Background(), newRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Stats().Delay; got <= 0 || got >= time.Hour {
+		t.Fatalf("delay = %v, want it derived from the observed latency", got)
+	}
+}
+
+func TestWithPercentileDelayValidation(t *testing.T) {
+	for _, p := range []float64{-0.1, 1.5, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("percentile %v: want a panic", p)
+				}
+			}()
+			hedging.WithPercentileDelay(p, 10)
+		}()
+	}
+	for _, window := range []int{-1, 0} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("window %d: want a panic", window)
+				}
+			}()
+			hedging.WithPercentileDelay(0.95, window)
+		}()
+	}
+	for _, p := range []float64{0, 0.95, 1} {
+		h := hedging.New(hedging.WithPercentileDelay(p, 10))
+		h.Stats()
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package hedging
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	defaultOptions = &options{
+		delay:     100 * time.Millisecond,
+		maxHedges: 1,
+	}
+)
+
+type options struct {
+	delay      time.Duration
+	maxHedges  int
+	percentile float64
+	window     int
+	minSamples int
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithDelay sets how long to wait for a response before sending a hedged copy of the request. When
+// WithPercentileDelay is used as well, this delay is only used until enough latencies were observed.
+// Defaults to 100ms.
+func WithDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.delay = d
+	}
+}
+
+// WithPercentileDelay derives the hedging delay from the given percentile (e.g. 0.95) of the latency of the
+// last `window` successful calls. The delay set by WithDelay is used until a tenth of the window was observed.
+// It panics if the percentile is not within [0, 1] or if the window is not positive.
+func WithPercentileDelay(percentile float64, window int) Option {
+	if !(percentile >= 0 && percentile <= 1) {
+		panic(fmt.Sprintf("hedging: percentile %v is not within [0, 1]", percentile))
+	}
+	if window <= 0 {
+		panic(fmt.Sprintf("hedging: window %d is not positive", window))
+	}
+	return func(o *options) {
+		o.percentile = percentile
+		o.window = window
+		o.minSamples = window / 10
+		if o.minSamples < 1 {
+			o.minSamples = 1
+		}
+	}
+}
+
+// WithMaxHedges sets how many hedged copies of a request may be sent in addition to the original one,
+// each one `delay` after the previous one. Defaults to 1.
+func WithMaxHedges(n int) Option {
+	return func(o *options) {
+		o.maxHedges = n
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+// Package logr adapts github.com/go-logr/logr loggers to logging.Logger.
+package logr
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+var (
+	_ logging.Logger       = &Logger{}
+	_ logging.LevelEnabler = &Logger{}
+)
+
+// Logger is a logging.Logger logging to a logr logger.
+type Logger struct {
+	logger logr.Logger
+}
+
+// New returns a Logger logging to l. The caller reported by l, if enabled, is the caller of Log.
+func New(l logr.Logger) *Logger {
+	return &Logger{logger: l.WithCallDepth(1)}
+}
+
+// Enabled implements logging.LevelEnabler. Errors are always logged by logr.
+func (l *Logger) Enabled(_ context.Context, level logging.Level) bool {
+	if level >= logging.LevelError {
+		return l.logger.GetSink() != nil
+	}
+	return l.logger.V(Verbosity(level)).Enabled()
+}
+
+// Log implements logging.Logger. Error and more severe levels are logged as errors, without error value, other
+// levels as info at the verbosity returned by Verbosity.
+func (l *Logger) Log(_ context.Context, level logging.Level, msg string, fields ...any) {
+	if len(fields)%2 == 1 {
+		// A key without value gets an empty string, as per logging.Fields.
+		fields = append(fields[:len(fields):len(fields)], "")
+	}
+	if level >= logging.LevelError {
+		l.logger.Error(nil, msg, fields...)
+		return
+	}
+	l.logger.V(Verbosity(level)).Info(msg, fields...)
+}
+
+// Verbosity returns the logr verbosity of the given level: 0 for info and warn, 1 for debug and one more for
+// every 4 levels below debug.
+func Verbosity(level logging.Level) int {
+	if level >= logging.LevelInfo {
+		return 0
+	}
+	return int(logging.LevelInfo-level+3) / 4
+}
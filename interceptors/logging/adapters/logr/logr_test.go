@@ -0,0 +1,75 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logr_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+	loglogr "github.com/svrana/go-connect-middleware/interceptors/logging/adapters/logr"
+)
+
+func TestVerbosity(t *testing.T) {
+	for _, tc := range []struct {
+		level logging.Level
+		want  int
+	}{
+		{level: logging.LevelDebug - 4, want: 2},
+		{level: logging.LevelDebug - 1, want: 2},
+		{level: logging.LevelDebug, want: 1},
+		{level: logging.LevelInfo - 1, want: 1},
+		{level: logging.LevelInfo, want: 0},
+		{level: logging.LevelWarn, want: 0},
+	} {
+		if got := loglogr.Verbosity(tc.level); got != tc.want {
+			t.Errorf("Verbosity(%d): expected %v, got %v", tc.level, tc.want, got)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var lines []map[string]any
+	l := loglogr.New(funcr.NewJSON(func(obj string) {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(obj), &entry); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, entry)
+	}, funcr.Options{LogCaller: funcr.All, Verbosity: 1}))
+
+	l.Log(context.Background(), logging.LevelDebug-4, "dropped", "a", 1)
+	l.Log(context.Background(), logging.LevelDebug, "hello", "a", 1, "b", "two", "c")
+	l.Log(context.Background(), logging.LevelError, "failed")
+
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %v", lines)
+	}
+	for k, want := range map[string]any{"level": 1.0, "msg": "hello", "a": 1.0, "b": "two", "c": ""} {
+		if got := lines[0][k]; got != want {
+			t.Errorf("field %q: expected %v, got %v", k, want, got)
+		}
+	}
+	if caller, _ := lines[0]["caller"].(map[string]any); caller["file"] != "logr_test.go" {
+		t.Errorf("expected caller in logr_test.go, got %v", lines[0]["caller"])
+	}
+	if _, ok := lines[1]["error"]; !ok || lines[1]["msg"] != "failed" {
+		t.Errorf("expected error entry, got %v", lines[1])
+	}
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	l := loglogr.New(funcr.New(func(prefix, args string) {}, funcr.Options{Verbosity: 0}))
+	if l.Enabled(context.Background(), logging.LevelDebug) {
+		t.Error("expected debug to be disabled")
+	}
+	for _, level := range []logging.Level{logging.LevelInfo, logging.LevelError} {
+		if !l.Enabled(context.Background(), level) {
+			t.Errorf("expected level %d to be enabled", level)
+		}
+	}
+}
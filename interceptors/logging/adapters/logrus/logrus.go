@@ -0,0 +1,73 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+// Package logrus adapts github.com/sirupsen/logrus loggers to logging.Logger.
+package logrus
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+var (
+	_ logging.Logger       = &Logger{}
+	_ logging.LevelEnabler = &Logger{}
+)
+
+// Logger is a logging.Logger logging to a logrus logger.
+//
+// logrus doesn't support skipping frames: with ReportCaller, the reported caller is Log.
+type Logger struct {
+	logger *logrus.Logger
+}
+
+// New returns a Logger logging to l.
+func New(l *logrus.Logger) *Logger {
+	return &Logger{logger: l}
+}
+
+// Enabled implements logging.LevelEnabler.
+func (l *Logger) Enabled(_ context.Context, level logging.Level) bool {
+	return l.logger.IsLevelEnabled(Level(level))
+}
+
+// Log implements logging.Logger. Fields are only converted if l is enabled for the level. The context is passed
+// on to the logrus entry, for hooks. Raw JSON, such as logged payloads, is embedded as is by the JSON formatter and
+// logged as text by the others.
+func (l *Logger) Log(ctx context.Context, level logging.Level, msg string, fields ...any) {
+	lvl := Level(level)
+	if !l.logger.IsLevelEnabled(lvl) {
+		return
+	}
+	_, jsonFormatter := l.logger.Formatter.(*logrus.JSONFormatter)
+	f := make(logrus.Fields, (len(fields)+1)/2)
+	i := logging.Fields(fields).Iterator()
+	for i.Next() {
+		k, v := i.At()
+		if raw, ok := v.(json.RawMessage); ok && !jsonFormatter {
+			v = string(raw)
+		}
+		f[k] = v
+	}
+	l.logger.WithContext(ctx).WithFields(f).Log(lvl, msg)
+}
+
+// Level returns the logrus level of the given level. Levels more verbose than debug are mapped to trace.
+func Level(level logging.Level) logrus.Level {
+	switch {
+	case level < logging.LevelDebug:
+		return logrus.TraceLevel
+	case level < logging.LevelInfo:
+		return logrus.DebugLevel
+	case level < logging.LevelWarn:
+		return logrus.InfoLevel
+	case level < logging.LevelError:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logrus_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+	loglogrus "github.com/svrana/go-connect-middleware/interceptors/logging/adapters/logrus"
+)
+
+func TestLevel(t *testing.T) {
+	for _, tc := range []struct {
+		level logging.Level
+		want  logrus.Level
+	}{
+		{level: logging.LevelDebug - 4, want: logrus.TraceLevel},
+		{level: logging.LevelDebug, want: logrus.DebugLevel},
+		{level: logging.LevelInfo, want: logrus.InfoLevel},
+		{level: logging.LevelInfo + 2, want: logrus.InfoLevel},
+		{level: logging.LevelWarn, want: logrus.WarnLevel},
+		{level: logging.LevelError, want: logrus.ErrorLevel},
+		{level: logging.LevelError + 4, want: logrus.ErrorLevel},
+	} {
+		if got := loglogrus.Level(tc.level); got != tc.want {
+			t.Errorf("Level(%d): expected %v, got %v", tc.level, tc.want, got)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.InfoLevel)
+	logger.SetReportCaller(true)
+	l := loglogrus.New(logger)
+
+	l.Log(context.Background(), logging.LevelDebug, "dropped", "a", 1)
+	l.Log(context.Background(), logging.LevelWarn, "hello", "a", 1, "b", "two", "c")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %q", lines)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]any{"level": "warning", "msg": "hello", "a": 1.0, "b": "two", "c": ""} {
+		if got := entry[k]; got != want {
+			t.Errorf("field %q: expected %v, got %v", k, want, got)
+		}
+	}
+	// logrus can't skip frames, the reported caller is the adapter.
+	if caller, _ := entry[logrus.FieldKeyFunc].(string); !strings.HasSuffix(caller, "adapters/logrus.(*Logger).Log") {
+		t.Errorf("expected the adapter as caller, got %q", caller)
+	}
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+	l := loglogrus.New(logger)
+	if l.Enabled(context.Background(), logging.LevelDebug) {
+		t.Error("expected debug to be disabled")
+	}
+	if !l.Enabled(context.Background(), logging.LevelInfo) {
+		t.Error("expected info to be enabled")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+// Package zap adapts go.uber.org/zap loggers to logging.Logger.
+package zap
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+var (
+	_ logging.Logger       = &Logger{}
+	_ logging.LevelEnabler = &Logger{}
+)
+
+// Logger is a logging.Logger logging to a zap logger.
+type Logger struct {
+	logger *zap.Logger
+}
+
+// New returns a Logger logging to l. The caller reported by l, if enabled, is the caller of Log.
+func New(l *zap.Logger) *Logger {
+	return &Logger{logger: l.WithOptions(zap.AddCallerSkip(1))}
+}
+
+// Enabled implements logging.LevelEnabler.
+func (l *Logger) Enabled(_ context.Context, level logging.Level) bool {
+	return l.logger.Core().Enabled(Level(level))
+}
+
+// Log implements logging.Logger. Fields are only converted if l is enabled for the level. Raw JSON, such as logged
+// payloads, is embedded as is by JSON encoders.
+func (l *Logger) Log(_ context.Context, level logging.Level, msg string, fields ...any) {
+	ce := l.logger.Check(Level(level), msg)
+	if ce == nil {
+		return
+	}
+	f := make([]zap.Field, 0, (len(fields)+1)/2)
+	i := logging.Fields(fields).Iterator()
+	for i.Next() {
+		k, v := i.At()
+		if raw, ok := v.(json.RawMessage); ok {
+			f = append(f, zap.Reflect(k, raw))
+			continue
+		}
+		f = append(f, zap.Any(k, v))
+	}
+	ce.Write(f...)
+}
+
+// Level returns the zap level of the given level. zap has no level below debug, so more verbose levels are
+// mapped to debug.
+func Level(level logging.Level) zapcore.Level {
+	switch {
+	case level < logging.LevelInfo:
+		return zapcore.DebugLevel
+	case level < logging.LevelWarn:
+		return zapcore.InfoLevel
+	case level < logging.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
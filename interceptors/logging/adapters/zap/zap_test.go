@@ -0,0 +1,78 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zap_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+	logzap "github.com/svrana/go-connect-middleware/interceptors/logging/adapters/zap"
+)
+
+func TestLevel(t *testing.T) {
+	for _, tc := range []struct {
+		level logging.Level
+		want  zapcore.Level
+	}{
+		{level: logging.LevelDebug - 4, want: zapcore.DebugLevel},
+		{level: logging.LevelDebug, want: zapcore.DebugLevel},
+		{level: logging.LevelInfo, want: zapcore.InfoLevel},
+		{level: logging.LevelInfo + 2, want: zapcore.InfoLevel},
+		{level: logging.LevelWarn, want: zapcore.WarnLevel},
+		{level: logging.LevelError, want: zapcore.ErrorLevel},
+		{level: logging.LevelError + 4, want: zapcore.ErrorLevel},
+	} {
+		if got := logzap.Level(tc.level); got != tc.want {
+			t.Errorf("Level(%d): expected %v, got %v", tc.level, tc.want, got)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := logzap.New(zap.New(core, zap.AddCaller()).With(zap.String("app", "test")))
+
+	l.Log(context.Background(), logging.LevelDebug, "dropped", "a", 1)
+	l.Log(context.Background(), logging.LevelWarn, "hello", "a", 1, "b", "two", "c")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zapcore.WarnLevel || e.Message != "hello" {
+		t.Errorf("unexpected entry %v %q", e.Level, e.Message)
+	}
+	fields := e.ContextMap()
+	for k, want := range map[string]any{"app": "test", "a": int64(1), "b": "two", "c": ""} {
+		if got := fields[k]; got != want {
+			t.Errorf("field %q: expected %v, got %v", k, want, got)
+		}
+	}
+	if file := filepath.Base(e.Caller.File); file != "zap_test.go" {
+		t.Errorf("expected caller in zap_test.go, got %s", e.Caller.File)
+	}
+	// Fields must not pile up on the logger.
+	l.Log(context.Background(), logging.LevelInfo, "again")
+	if n := len(logs.AllUntimed()[1].Context); n != 1 {
+		t.Errorf("expected 1 field on the second entry, got %d", n)
+	}
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	core, _ := observer.New(zapcore.InfoLevel)
+	l := logzap.New(zap.New(core))
+	if l.Enabled(context.Background(), logging.LevelDebug) {
+		t.Error("expected debug to be disabled")
+	}
+	if !l.Enabled(context.Background(), logging.LevelInfo) {
+		t.Error("expected info to be enabled")
+	}
+}
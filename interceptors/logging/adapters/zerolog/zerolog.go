@@ -0,0 +1,63 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+// Package zerolog adapts github.com/rs/zerolog loggers to logging.Logger.
+package zerolog
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+var (
+	_ logging.Logger       = &Logger{}
+	_ logging.LevelEnabler = &Logger{}
+)
+
+// Logger is a logging.Logger logging to a zerolog logger.
+type Logger struct {
+	logger zerolog.Logger
+}
+
+// New returns a Logger logging to l. The caller reported by l, if enabled, is the caller of Log.
+func New(l zerolog.Logger) *Logger {
+	return &Logger{logger: l}
+}
+
+// Enabled implements logging.LevelEnabler.
+func (l *Logger) Enabled(_ context.Context, level logging.Level) bool {
+	lvl := Level(level)
+	return lvl >= l.logger.GetLevel() && lvl >= zerolog.GlobalLevel()
+}
+
+// Log implements logging.Logger. Fields are only converted if l is enabled for the level.
+func (l *Logger) Log(_ context.Context, level logging.Level, msg string, fields ...any) {
+	e := l.logger.WithLevel(Level(level))
+	if e == nil {
+		return
+	}
+	if len(fields)%2 == 1 {
+		// A key without value gets an empty string, as per logging.Fields.
+		fields = append(fields[:len(fields):len(fields)], "")
+	}
+	e.Fields(fields).CallerSkipFrame(1).Msg(msg)
+}
+
+// Level returns the zerolog level of the given level. Levels more verbose than debug are mapped to trace.
+func Level(level logging.Level) zerolog.Level {
+	switch {
+	case level < logging.LevelDebug:
+		return zerolog.TraceLevel
+	case level < logging.LevelInfo:
+		return zerolog.DebugLevel
+	case level < logging.LevelWarn:
+		return zerolog.InfoLevel
+	case level < logging.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zerolog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+	logzerolog "github.com/svrana/go-connect-middleware/interceptors/logging/adapters/zerolog"
+)
+
+func TestLevel(t *testing.T) {
+	for _, tc := range []struct {
+		level logging.Level
+		want  zerolog.Level
+	}{
+		{level: logging.LevelDebug - 4, want: zerolog.TraceLevel},
+		{level: logging.LevelDebug, want: zerolog.DebugLevel},
+		{level: logging.LevelInfo, want: zerolog.InfoLevel},
+		{level: logging.LevelInfo + 2, want: zerolog.InfoLevel},
+		{level: logging.LevelWarn, want: zerolog.WarnLevel},
+		{level: logging.LevelError, want: zerolog.ErrorLevel},
+		{level: logging.LevelError + 4, want: zerolog.ErrorLevel},
+	} {
+		if got := logzerolog.Level(tc.level); got != tc.want {
+			t.Errorf("Level(%d): expected %v, got %v", tc.level, tc.want, got)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := logzerolog.New(zerolog.New(&buf).Level(zerolog.InfoLevel).With().Str("app", "test").Caller().Logger())
+
+	l.Log(context.Background(), logging.LevelDebug, "dropped", "a", 1)
+	l.Log(context.Background(), logging.LevelWarn, "hello", "a", 1, "b", "two", "c")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %q", lines)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]any{"level": "warn", "message": "hello", "app": "test", "a": 1.0, "b": "two", "c": ""} {
+		if got := entry[k]; got != want {
+			t.Errorf("field %q: expected %v, got %v", k, want, got)
+		}
+	}
+	if caller, _ := entry[zerolog.CallerFieldName].(string); !strings.Contains(caller, "zerolog_test.go") {
+		t.Errorf("expected caller in zerolog_test.go, got %q", caller)
+	}
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	l := logzerolog.New(zerolog.New(nil).Level(zerolog.InfoLevel))
+	if l.Enabled(context.Background(), logging.LevelDebug) {
+		t.Error("expected debug to be disabled")
+	}
+	if !l.Enabled(context.Background(), logging.LevelInfo) {
+		t.Error("expected info to be enabled")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+// discardLogger consumes fields like a real logger would, without writing them.
+var discardLogger = LoggerFunc(func(_ context.Context, _ Level, _ string, fields ...any) {
+	i := Fields(fields).Iterator()
+	for i.Next() {
+		_, _ = i.At()
+	}
+})
+
+func benchmarkCall(b *testing.B, err error) {
+	report := reportable(discardLogger, evaluateServerOpt([]Option{WithLogOnEvents(StartCall, FinishCall)}))
+	meta := interceptors.CallMeta{Service: "ping.v1.PingService", Method: "Ping", Typ: connect.StreamTypeUnary}
+	ctx := InjectFields(context.Background(), Fields{"request_id", "0e7c5c4e-5b0f-4b5a-9d7e-6c3f0f1d2a3b", "user", "bob"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r, _ := report(ctx, meta)
+		r.PostMsgReceive(nil, nil, time.Millisecond)
+		r.PostMsgSend(nil, err, time.Millisecond)
+		r.PostCall(err, 2*time.Millisecond)
+	}
+}
+
+// BenchmarkReporter measures the cost of logging the start and end of a unary call. Failed calls allocate twice
+// more: the message of the error, and its boxing as a field value.
+func BenchmarkReporter(b *testing.B) {
+	b.Run("ok", func(b *testing.B) {
+		benchmarkCall(b, nil)
+	})
+	b.Run("error", func(b *testing.B) {
+		benchmarkCall(b, connect.NewError(connect.CodeNotFound, errors.New("not found")))
+	})
+}
+
+func BenchmarkInjectExtractFields(b *testing.B) {
+	ctx := InjectFields(context.Background(), Fields{"request_id", "0e7c5c4e", "user", "bob"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ExtractFields(InjectLogField(ctx, "tenant", "acme"))
+	}
+}
+
+func BenchmarkFieldsWithUnique(b *testing.B) {
+	f := Fields{"component", "server", "service", "ping.v1.PingService", "method", "Ping", "method_type", "unary"}
+	add := Fields{"request_id", "0e7c5c4e", "method", "Other"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f.WithUnique(add)
+	}
+}
@@ -8,14 +8,18 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/bufbuild/connect-go"
-	"github.com/svrana/go-connect-middleware/interceptors/logging"
+	"connectrpc.com/connect"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
 )
 
 // InterceptorLogger adapts zap logger to interceptor logger.
-// This code is simple enough to be copied and not imported.
+// This code is simple enough to be copied and not imported. For a maintained adapter, see the
+// interceptors/logging/adapters/zap package.
 func InterceptorLogger(l *zap.Logger) logging.Logger {
+	l = l.WithOptions(zap.AddCallerSkip(1))
 	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
 		f := make([]zap.Field, 0, len(fields)/2)
 		i := logging.Fields(fields).Iterator()
@@ -23,17 +27,16 @@ func InterceptorLogger(l *zap.Logger) logging.Logger {
 			k, v := i.At()
 			f = append(f, zap.Any(k, v))
 		}
-		l = l.WithOptions(zap.AddCallerSkip(1)).With(f...)
 
 		switch lvl {
 		case logging.LevelDebug:
-			l.Debug(msg)
+			l.Debug(msg, f...)
 		case logging.LevelInfo:
-			l.Info(msg)
+			l.Info(msg, f...)
 		case logging.LevelWarn:
-			l.Warn(msg)
+			l.Warn(msg, f...)
 		case logging.LevelError:
-			l.Error(msg)
+			l.Error(msg, f...)
 		default:
 			panic(fmt.Sprintf("unknown level %v", lvl))
 		}
@@ -54,11 +57,14 @@ func ExampleInterceptorLogger() {
 	)
 
 	mux := http.NewServeMux()
-	mux.Handle(
-		// imaginary user service
-		pingv1connect.NewPingServiceHandler(
-			pingServer{},
-			interceptors, // your middleware here
-		),
-	)
+	// imaginary ping service, usually registered with the handler constructor generated by protoc-gen-connect-go.
+	mux.Handle("/ping.v1.PingService/Ping", connect.NewUnaryHandler(
+		"/ping.v1.PingService/Ping",
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			// Handler logs carry the fields of the interceptor (service, method...).
+			logging.Log(ctx, logging.LevelInfo, "pinged", "value", req.Msg.GetValue())
+			return connect.NewResponse(req.Msg), nil
+		},
+		interceptors, // your middleware here
+	))
 }
@@ -0,0 +1,90 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// HeaderFields selects the headers (or trailers) logged as fields, named after their lower-case name, e.g.
+// "request.header.user-agent". Values of the same header are joined with commas. Values of redacted headers (see
+// WithRedactedHeaders) are replaced by RedactedValue.
+type HeaderFields struct {
+	// Allow lists the logged headers, "*" logging all headers not denied. If empty, no header is logged.
+	Allow []string
+	// Deny lists headers never logged, even if allowed.
+	Deny []string
+	// MaxValueSize is the maximum size, in bytes, of the value of a header. Longer values are truncated and marked as
+	// such. Zero or less doesn't limit the size.
+	MaxValueSize int
+}
+
+// Headers of Allow, Deny and WithRedactedHeaders are case-insensitive names or path.Match patterns, e.g.
+// "x-forwarded-*". The name must be lower-case.
+func matchHeader(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *HeaderFields) allowed(name string) bool {
+	name = strings.ToLower(name)
+	if matchHeader(h.Deny, name) {
+		return false
+	}
+	return matchHeader(h.Allow, name)
+}
+
+// WithRequestHeaders logs the selected request headers on the "started call" and "finished call" events, under
+// "request.header.<name>".
+func WithRequestHeaders(h HeaderFields) Option {
+	return func(o *options) {
+		o.requestHeaders = &h
+	}
+}
+
+// WithResponseHeaders logs the selected response headers on the "finished call" event, under
+// "response.header.<name>". The metadata of errors (see connect.Error.Meta) is logged along the response headers.
+// Response headers aren't known for client streams finished by their context.
+func WithResponseHeaders(h HeaderFields) Option {
+	return func(o *options) {
+		o.responseHeaders = &h
+	}
+}
+
+// WithResponseTrailers logs the selected response trailers on the "finished call" event, under
+// "response.trailer.<name>". Trailers aren't known for client streams finished by their context.
+func WithResponseTrailers(h HeaderFields) Option {
+	return func(o *options) {
+		o.responseTrailers = &h
+	}
+}
+
+// appendHeaderFields appends the fields of the headers selected by hf, sorted by name, to fields.
+func (o *options) appendHeaderFields(fields Fields, prefix string, hf *HeaderFields, h http.Header) Fields {
+	if hf == nil || len(h) == 0 {
+		return fields
+	}
+	names := make([]string, 0, len(h))
+	for name := range h {
+		if hf.allowed(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := RedactedValue
+		if !o.redactedHeader(name) {
+			value = truncate(strings.Join(h[name], ", "), hf.MaxValueSize)
+		}
+		fields = fields.AppendUnique(Fields{prefix + strings.ToLower(name), value})
+	}
+	return fields
+}
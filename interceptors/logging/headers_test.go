@@ -0,0 +1,168 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestHeaderFieldsAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		h       HeaderFields
+		allowed []string
+		denied  []string
+	}{
+		{name: "empty", denied: []string{"User-Agent", "X-Request-Id"}},
+		{name: "all", h: HeaderFields{Allow: []string{"*"}}, allowed: []string{"User-Agent", "X-Request-Id"}},
+		{
+			name:    "names and patterns",
+			h:       HeaderFields{Allow: []string{"User-Agent", "x-forwarded-*"}},
+			allowed: []string{"user-agent", "X-Forwarded-For"},
+			denied:  []string{"X-Request-Id", "X-Forwarded"},
+		},
+		{
+			name:    "deny wins",
+			h:       HeaderFields{Allow: []string{"*"}, Deny: []string{"X-Internal-*"}},
+			allowed: []string{"User-Agent"},
+			denied:  []string{"X-Internal-Token"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range tc.allowed {
+				if !tc.h.allowed(name) {
+					t.Errorf("%s is not allowed", name)
+				}
+			}
+			for _, name := range tc.denied {
+				if tc.h.allowed(name) {
+					t.Errorf("%s is allowed", name)
+				}
+			}
+		})
+	}
+}
+
+// finishedCalls returns the header fields of the "finished call" events logged as JSON in buf, by method.
+func finishedCalls(t *testing.T, buf *bytes.Buffer) map[string]map[string]string {
+	calls := map[string]map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		if e["msg"] != "finished call" {
+			continue
+		}
+		headers := map[string]string{}
+		for k, v := range e {
+			if strings.HasPrefix(k, "request.header.") || strings.HasPrefix(k, "response.") {
+				headers[k], _ = v.(string)
+			}
+		}
+		calls[e["method"].(string)] = headers
+	}
+	return calls
+}
+
+func TestHeaderLogging(t *testing.T) {
+	const (
+		echoProcedure   = "/test.v1.TestService/Echo"
+		failProcedure   = "/test.v1.TestService/Fail"
+		streamProcedure = "/test.v1.TestService/Stream"
+	)
+	var serverLog, clientLog bytes.Buffer
+	opts := []Option{
+		WithLogOnEvents(FinishCall),
+		WithRequestHeaders(HeaderFields{Allow: []string{"x-request-*", "authorization"}}),
+		WithResponseHeaders(HeaderFields{Allow: []string{"x-*"}}),
+		WithResponseTrailers(HeaderFields{Allow: []string{"x-*"}}),
+	}
+	serverInterceptors := connect.WithInterceptors(
+		UnaryServerInterceptor(NewJSONLogger(&serverLog), opts...),
+		StreamServerInterceptor(NewJSONLogger(&serverLog), opts...),
+	)
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		resp := connect.NewResponse(req.Msg)
+		resp.Header().Set("X-Response", "unary")
+		resp.Trailer().Set("X-Trailer", "unary")
+		return resp, nil
+	}, serverInterceptors))
+	mux.Handle(failProcedure, connect.NewUnaryHandler(failProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		err := connect.NewError(connect.CodeNotFound, errors.New("not found"))
+		err.Meta().Set("X-Error", "meta")
+		return nil, err
+	}, serverInterceptors))
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+		stream.ResponseHeader().Set("X-Response", "stream")
+		stream.ResponseTrailer().Set("X-Trailer", "stream")
+		return stream.Send(req.Msg)
+	}, serverInterceptors))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	// Clients log not found errors at LevelDebug.
+	clientInterceptors := connect.WithInterceptors(
+		UnaryClientInterceptor(NewJSONLogger(&clientLog, WithMinLevel(LevelDebug)), opts...),
+		StreamClientInterceptor(NewJSONLogger(&clientLog, WithMinLevel(LevelDebug)), opts...),
+	)
+	newRequest := func() *connect.Request[wrapperspb.StringValue] {
+		req := connect.NewRequest(wrapperspb.String("hi"))
+		req.Header().Set("X-Request-Id", "42")
+		req.Header().Set("Authorization", "Bearer secret")
+		return req
+	}
+	echo := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+echoProcedure, clientInterceptors)
+	if _, err := echo.CallUnary(context.Background(), newRequest()); err != nil {
+		t.Fatal(err)
+	}
+	fail := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+failProcedure, clientInterceptors)
+	if _, err := fail.CallUnary(context.Background(), newRequest()); connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("got %v, want not found", err)
+	}
+	streamClient := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+streamProcedure, clientInterceptors)
+	stream, err := streamClient.CallServerStream(context.Background(), newRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Receive() {
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	request := map[string]string{"request.header.x-request-id": "42", "request.header.authorization": RedactedValue}
+	with := func(fields ...string) map[string]string {
+		m := map[string]string{}
+		for k, v := range request {
+			m[k] = v
+		}
+		for i := 0; i < len(fields); i += 2 {
+			m[fields[i]] = fields[i+1]
+		}
+		return m
+	}
+	want := map[string]map[string]string{
+		"Echo":   with("response.header.x-response", "unary", "response.trailer.x-trailer", "unary"),
+		"Fail":   with("response.header.x-error", "meta"),
+		"Stream": with("response.header.x-response", "stream", "response.trailer.x-trailer", "stream"),
+	}
+	for side, buf := range map[string]*bytes.Buffer{"server": &serverLog, "client": &clientLog} {
+		if got := finishedCalls(t, buf); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", side, got, want)
+		}
+	}
+}
@@ -5,8 +5,10 @@ package logging
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"io"
+	"net/http"
+	"sync"
 	"time"
 
 	"connectrpc.com/connect"
@@ -18,37 +20,144 @@ import (
 type reporter struct {
 	interceptors.CallMeta
 
-	ctx             context.Context
-	kind            string
+	ctx  context.Context
+	kind string
+
+	// mu serializes events, which streams may report concurrently when sending and receiving from different
+	// goroutines. It guards startCallLogged and the event fields.
+	mu              sync.Mutex
 	startCallLogged bool
 
-	opts   *options
+	opts *options
+	// fields are the fields of the call, shared with fieldsCtx.
 	fields Fields
+	// fieldsCtx is the context returned to the interceptor, allocated along with the reporter.
+	fieldsCtx fieldsContext
+	// eventPrefix holds fields, start time and deadline. See eventFields.
+	eventPrefix Fields
+	startTime   time.Time
+	deadline    time.Time
+	// headers are the headers and trailers of the call, set before any event.
+	headers interceptors.Headers
+	// fieldsArr and eventArr back fields and eventPrefix until they outgrow them, which saves allocating them.
+	fieldsArr [24]any
+	eventArr  [28]any
+
 	logger Logger
+	// enabler is the logger, if it implements LevelEnabler.
+	enabler LevelEnabler
+}
+
+// eventFields returns the fields of the call followed by its start time and deadline, which are only formatted once
+// an event is logged. The returned fields are never modified and have no spare capacity: the fields appended by an
+// event go to a new array, which Loggers are free to keep.
+func (c *reporter) eventFields() Fields {
+	if c.eventPrefix == nil {
+		f := append(Fields(c.eventArr[:0]), c.fields...)
+		f = f.AppendUnique(Fields{"start_time", c.startTime.Format(c.opts.timestampFormat)})
+		if !c.deadline.IsZero() {
+			f = f.AppendUnique(Fields{"request.deadline", c.deadline.Format(c.opts.timestampFormat)})
+		}
+		c.eventPrefix = f[:len(f):len(f)]
+	}
+	return c.eventPrefix
+}
+
+// enabled reports whether the logger would log events of the given level.
+func (c *reporter) enabled(level Level) bool {
+	return c.enabler == nil || c.enabler.Enabled(c.ctx, level)
 }
 
 func (c *reporter) PostCall(err error, duration time.Duration) {
 	if !has(c.opts.loggableEvents, FinishCall) {
 		return
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if err == io.EOF {
 		err = nil
 	}
 
-	fields := c.fields.WithUnique(ExtractFields(c.ctx))
-
-	var level Level
+	level := LevelInfo
+	var code connect.Code
 	if err != nil {
-		code := c.opts.codeFunc(err)
-		fields = fields.AppendUnique(Fields{"code", code.String()})
+		code = c.opts.codeFunc(err)
 		level = c.opts.levelFunc(code)
-	} else {
-		level = LevelInfo
 	}
+	if !c.enabled(level) {
+		return
+	}
+
+	// The error and duration fields are appended at once, from an array which doesn't escape.
+	var addArr [8]any
+	add := Fields(addArr[:0])
 	if err != nil {
-		fields = fields.AppendUnique(Fields{"error", fmt.Sprintf("%v", err)})
+		add = append(add, "code", codeString(code), "error", err.Error())
+	}
+	add = append(add, c.opts.durationFieldFunc(duration)...)
+	fields := c.eventFields().AppendUnique(add)
+	fields = c.opts.appendHeaderFields(fields, "request.header.", c.opts.requestHeaders, c.requestHeader())
+	if c.headers != nil {
+		if c.opts.responseHeaders != nil {
+			fields = c.opts.appendHeaderFields(fields, "response.header.", c.opts.responseHeaders, responseHeader(c.headers, err))
+		}
+		fields = c.opts.appendHeaderFields(fields, "response.trailer.", c.opts.responseTrailers, c.headers.ResponseTrailer())
+	}
+	c.logger.Log(c.ctx, level, "finished call", fields...)
+}
+
+func (c *reporter) logStartCall(level Level, fields Fields, duration time.Duration) {
+	c.startCallLogged = true
+	fields = fields.AppendUnique(c.opts.durationFieldFunc(duration))
+	fields = c.opts.appendHeaderFields(fields, "request.header.", c.opts.requestHeaders, c.requestHeader())
+	c.logger.Log(c.ctx, level, "started call", fields...)
+}
+
+// SetHeaders implements interceptors.HeadersReporter.
+func (c *reporter) SetHeaders(h interceptors.Headers) {
+	c.headers = h
+}
+
+// requestHeader returns the headers of the request, if known.
+func (c *reporter) requestHeader() http.Header {
+	if c.headers != nil {
+		return c.headers.RequestHeader()
 	}
-	c.logger.Log(c.ctx, level, "finished call", fields.AppendUnique(c.opts.durationFieldFunc(duration))...)
+	return nil
+}
+
+// codeStrings holds the strings of the codes, already boxed to be logged as fields without allocating.
+var codeStrings = func() map[connect.Code]any {
+	m := make(map[connect.Code]any, len(interceptors.AllCodes))
+	for _, code := range interceptors.AllCodes {
+		m[code] = code.String()
+	}
+	return m
+}()
+
+// codeString returns the string of the code, as a field value.
+func codeString(code connect.Code) any {
+	if s, ok := codeStrings[code]; ok {
+		return s
+	}
+	return code.String()
+}
+
+// responseHeader returns the headers of the response along with the metadata of err, if it is a connect.Error.
+func responseHeader(h interceptors.Headers, err error) http.Header {
+	header := h.ResponseHeader()
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) || len(connectErr.Meta()) == 0 {
+		return header
+	}
+	merged := header.Clone()
+	if merged == nil {
+		merged = make(http.Header, len(connectErr.Meta()))
+	}
+	for k, v := range connectErr.Meta() {
+		merged[k] = append(merged[k], v...)
+	}
+	return merged
 }
 
 func (c *reporter) errorToLevel(err error) Level {
@@ -59,64 +168,137 @@ func (c *reporter) errorToLevel(err error) Level {
 }
 
 func (c *reporter) PostMsgSend(res connect.AnyResponse, err error, duration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	logStart := !c.startCallLogged && has(c.opts.loggableEvents, StartCall)
+	logPayload := err == nil && has(c.opts.loggableEvents, PayloadSent)
+	if !logStart && !logPayload {
+		return
+	}
 	logLvl := c.errorToLevel(err)
-	fields := c.fields.WithUnique(ExtractFields(c.ctx))
+	if !c.enabled(logLvl) {
+		c.startCallLogged = c.startCallLogged || logStart
+		return
+	}
+
+	fields := c.eventFields()
 	if err != nil {
-		fields = fields.AppendUnique(Fields{"error", fmt.Sprintf("%v", err)})
+		fields = fields.AppendUnique(Fields{"error", err.Error()})
 	}
-	if !c.startCallLogged && has(c.opts.loggableEvents, StartCall) {
-		c.startCallLogged = true
-		c.logger.Log(c.ctx, logLvl, "started call", fields.AppendUnique(c.opts.durationFieldFunc(duration))...)
+	if logStart {
+		c.logStartCall(logLvl, fields, duration)
 	}
 
-	if err != nil || !has(c.opts.loggableEvents, PayloadSent) {
+	if !logPayload {
 		return
 	}
+	fields = fields.AppendUnique(Fields{"send.duration", duration.String()})
 	if c.CallMeta.IsClient {
-		fields = fields.AppendUnique(Fields{"send.duration", duration.String(), "request.content", res})
+		fields = c.appendPayload(fields, "request.content", res)
 		c.logger.Log(c.ctx, logLvl, "request sent", fields...)
 	} else {
-		fields = fields.AppendUnique(Fields{"send.duration", duration.String(), "response.content", res})
+		fields = c.appendPayload(fields, "response.content", res)
 		c.logger.Log(c.ctx, logLvl, "response sent", fields...)
 	}
 }
 
 func (c *reporter) PostMsgReceive(req connect.AnyRequest, err error, duration time.Duration) {
-	var logLvl Level
-	if err != nil {
-		logLvl = c.opts.levelFunc(c.opts.codeFunc(err))
-	} else {
-		logLvl = LevelInfo
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	logStart := !c.startCallLogged && has(c.opts.loggableEvents, StartCall)
+	logPayload := err == nil && has(c.opts.loggableEvents, PayloadReceived)
+	if !logStart && !logPayload {
+		return
+	}
+	logLvl := c.errorToLevel(err)
+	if !c.enabled(logLvl) {
+		c.startCallLogged = c.startCallLogged || logStart
+		return
 	}
-	fields := c.fields.WithUnique(ExtractFields(c.ctx))
+
+	fields := c.eventFields()
 	if err != nil {
-		fields = fields.AppendUnique(Fields{"error", fmt.Sprintf("%v", err)})
+		fields = fields.AppendUnique(Fields{"error", err.Error()})
 	}
-	if !c.startCallLogged && has(c.opts.loggableEvents, StartCall) {
-		c.startCallLogged = true
-		c.logger.Log(c.ctx, logLvl, "started call", fields.AppendUnique(c.opts.durationFieldFunc(duration))...)
+	if logStart {
+		c.logStartCall(logLvl, fields, duration)
 	}
 
-	if err != nil || !has(c.opts.loggableEvents, PayloadReceived) {
+	if !logPayload {
 		return
 	}
+	fields = fields.AppendUnique(Fields{"recv.duration", duration.String()})
 	if !c.CallMeta.IsClient {
-		fields = fields.AppendUnique(Fields{"recv.duration", duration.String(), "request.content", req})
+		fields = c.appendPayload(fields, "request.content", req)
 		c.logger.Log(c.ctx, logLvl, "request received", fields...)
 	} else {
-		fields = fields.AppendUnique(Fields{"recv.duration", duration.String(), "response.content", req})
+		fields = c.appendPayload(fields, "response.content", req)
 		c.logger.Log(c.ctx, logLvl, "response received", fields...)
 	}
 }
 
+// appendPayload appends the field logging the message of payload, unless there is none.
+func (c *reporter) appendPayload(fields Fields, key string, payload any) Fields {
+	if v, ok := c.opts.payloadValue(payload); ok {
+		return fields.AppendUnique(Fields{key, v})
+	}
+	return fields
+}
+
+// traceFields returns the fields correlating log lines with the span found in the context, if any.
+func traceFields(ctx context.Context, f TraceContextFunc, keys *TraceFieldKeys) Fields {
+	traceID, spanID, sampled := f(ctx)
+	if traceID == "" {
+		return nil
+	}
+	fields := make(Fields, 0, 6)
+	if keys.TraceID != "" {
+		if keys.FormatTraceID != nil {
+			traceID = keys.FormatTraceID(traceID)
+		}
+		fields = append(fields, keys.TraceID, traceID)
+	}
+	if keys.SpanID != "" && spanID != "" {
+		if keys.FormatSpanID != nil {
+			spanID = keys.FormatSpanID(spanID)
+		}
+		fields = append(fields, keys.SpanID, spanID)
+	}
+	if keys.Sampled != "" {
+		fields = append(fields, keys.Sampled, sampled)
+	}
+	return fields
+}
+
 func reportable(logger Logger, opts *options) interceptors.CommonReportableFunc {
+	enabler, _ := logger.(LevelEnabler)
+	common := &commonFieldsCache{}
+	procedures := &procedureOptionsCache{opts: opts}
 	return func(ctx context.Context, c interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+		opts := procedures.get(c)
 		kind := KindServerFieldValue
 		if c.IsClient {
 			kind = KindClientFieldValue
 		}
 
-		fields := ExtractFields(ctx).WithUnique(newCommonFields(kind, c))
+		r := &reporter{
+			CallMeta:        c,
+			ctx:             ctx,
+			startCallLogged: false,
+			opts:            opts,
+			startTime:       time.Now(),
+			logger:          logger,
+			enabler:         enabler,
+			kind:            kind,
+		}
+		if d, ok := ctx.Deadline(); ok {
+			r.deadline = d
+		}
+
+		fields := append(Fields(r.fieldsArr[:0]), fieldsFromContext(ctx)...)
+		fields = fields.AppendUnique(common.get(kind, c))
 		if !c.IsClient {
 			// FIXME
 			if peer, ok := peer.FromContext(ctx); ok {
@@ -126,20 +308,14 @@ func reportable(logger Logger, opts *options) interceptors.CommonReportableFunc
 		if opts.fieldsFromCtxFn != nil {
 			fields = fields.AppendUnique(opts.fieldsFromCtxFn(ctx))
 		}
-
-		singleUseFields := Fields{"start_time", time.Now().Format(opts.timestampFormat)}
-		if d, ok := ctx.Deadline(); ok {
-			singleUseFields = singleUseFields.AppendUnique(Fields{"request.deadline", d.Format(opts.timestampFormat)})
+		if opts.traceContextFn != nil {
+			fields = fields.AppendUnique(traceFields(ctx, opts.traceContextFn, opts.traceFieldKeys))
 		}
-		return &reporter{
-			CallMeta:        c,
-			ctx:             ctx,
-			startCallLogged: false,
-			opts:            opts,
-			fields:          fields.WithUnique(singleUseFields),
-			logger:          logger,
-			kind:            kind,
-		}, InjectFields(ctx, fields)
+		r.fields = fields[:len(fields):len(fields)]
+		// Fields start with the (unique) fields of ctx, they are already what InjectFields(ctx, fields) would store.
+		// The logger is stored along, for FromContext.
+		r.fieldsCtx = fieldsContext{Context: ctx, fields: r.fields, logger: logger}
+		return r, &r.fieldsCtx
 	}
 }
 
@@ -150,3 +326,27 @@ func UnaryServerInterceptor(logger Logger, opts ...Option) connect.UnaryIntercep
 	o := evaluateServerOpt(opts)
 	return interceptors.UnaryServerInterceptor(reportable(logger, o))
 }
+
+// StreamServerInterceptor returns a new stream server interceptor that optionally logs endpoint handling.
+// Logger will read existing and write new logging.Fields available in current context.
+// See `ExtractFields` and `InjectFields` for details.
+func StreamServerInterceptor(logger Logger, opts ...Option) connect.Interceptor {
+	o := evaluateServerOpt(opts)
+	return interceptors.StreamServerInterceptor(reportable(logger, o))
+}
+
+// UnaryClientInterceptor returns a new unary client interceptor that optionally logs the execution of external
+// connect calls. Logger will read existing and write new logging.Fields available in current context.
+// See `ExtractFields` and `InjectFields` for details.
+func UnaryClientInterceptor(logger Logger, opts ...Option) connect.UnaryInterceptorFunc {
+	o := evaluateClientOpt(opts)
+	return interceptors.UnaryClientInterceptor(reportable(logger, o))
+}
+
+// StreamClientInterceptor returns a new streaming client interceptor that optionally logs the execution of external
+// connect calls. Logger will read existing and write new logging.Fields available in current context.
+// See `ExtractFields` and `InjectFields` for details.
+func StreamClientInterceptor(logger Logger, opts ...Option) connect.Interceptor {
+	o := evaluateClientOpt(opts)
+	return interceptors.StreamClientInterceptor(reportable(logger, o))
+}
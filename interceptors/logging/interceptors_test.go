@@ -0,0 +1,106 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+// minLevelLogger is a LoggerFunc only enabled from minLevel.
+type minLevelLogger struct {
+	LoggerFunc
+	minLevel Level
+}
+
+func (l minLevelLogger) Enabled(_ context.Context, level Level) bool {
+	return level >= l.minLevel
+}
+
+func TestReporterSkipsDisabledLevels(t *testing.T) {
+	var logged []string
+	logger := minLevelLogger{
+		LoggerFunc: func(_ context.Context, _ Level, msg string, _ ...any) {
+			logged = append(logged, msg)
+		},
+		minLevel: LevelError,
+	}
+	opts := evaluateServerOpt([]Option{WithLogOnEvents(StartCall, PayloadReceived, PayloadSent, FinishCall)})
+	meta := interceptors.CallMeta{Service: "ping.v1.PingService", Method: "Ping", Typ: connect.StreamTypeUnary}
+	ctx := InjectFields(context.Background(), Fields{"user", "bob"})
+	req := connect.NewRequest(wrapperspb.String("ping"))
+	res := connect.NewResponse(wrapperspb.String("pong"))
+	notFound := connect.NewError(connect.CodeNotFound, errors.New("not found"))
+
+	report := reportable(logger, opts)
+	var r *reporter
+	allocs := testing.AllocsPerRun(100, func() {
+		rr, _ := report(ctx, meta)
+		r = rr.(*reporter)
+		r.PostMsgReceive(req, nil, time.Millisecond)
+		r.PostMsgSend(res, notFound, time.Millisecond)
+		r.PostCall(notFound, 2*time.Millisecond)
+	})
+	if len(logged) != 0 {
+		t.Fatalf("logged %v, want nothing below LevelError", logged)
+	}
+	if r.eventPrefix != nil {
+		t.Fatalf("event fields were built: %v", r.eventPrefix)
+	}
+	// The reporter and connect.CodeOf, whose errors.As target escapes, are the only allocations.
+	if allocs > 2 {
+		t.Fatalf("%v allocations per call, want no more than the reporter's", allocs)
+	}
+
+	logger.minLevel = LevelDebug
+	rr, _ := reportable(logger, opts)(ctx, meta)
+	rr.PostMsgReceive(req, nil, time.Millisecond)
+	rr.PostMsgSend(res, nil, time.Millisecond)
+	rr.PostCall(nil, 2*time.Millisecond)
+	want := []string{"started call", "request received", "response sent", "finished call"}
+	if len(logged) != len(want) {
+		t.Fatalf("logged %v, want %v", logged, want)
+	}
+	for i := range want {
+		if logged[i] != want[i] {
+			t.Fatalf("logged %v, want %v", logged, want)
+		}
+	}
+}
+
+func TestReporterEventsOwnFields(t *testing.T) {
+	type event struct {
+		retained, copied []any
+	}
+	var events []event
+	logger := LoggerFunc(func(_ context.Context, _ Level, _ string, fields ...any) {
+		events = append(events, event{retained: fields, copied: append([]any(nil), fields...)})
+	})
+	opts := evaluateServerOpt([]Option{WithLogOnEvents(StartCall, PayloadReceived, PayloadSent, FinishCall)})
+	meta := interceptors.CallMeta{Service: "ping.v1.PingService", Method: "Ping", Typ: connect.StreamTypeUnary}
+	ctx, cancel := context.WithTimeout(InjectFields(context.Background(), Fields{"user", "bob"}), time.Minute)
+	defer cancel()
+
+	r, _ := reportable(logger, opts)(ctx, meta)
+	r.PostMsgReceive(connect.NewRequest(wrapperspb.String("ping")), nil, time.Millisecond)
+	r.PostMsgSend(connect.NewResponse(wrapperspb.String("pong")), nil, time.Millisecond)
+	r.PostCall(nil, 2*time.Millisecond)
+
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+	for i, e := range events {
+		if !reflect.DeepEqual(e.retained, e.copied) {
+			t.Fatalf("event %d: fields retained by the logger changed to %v, were %v", i, e.retained, e.copied)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"io"
+)
+
+var (
+	_ Logger       = &JSONLogger{}
+	_ LevelEnabler = &JSONLogger{}
+)
+
+// JSONLogger is a Logger writing events as JSON objects, one per line, e.g.
+//
+//	{"time":"2023-11-10T23:00:00Z","level":"INFO","msg":"finished call","component":"server","code":"ok"}
+//
+// Fields are written in order, after time, level and msg. It is safe for concurrent use.
+type JSONLogger struct {
+	writerLogger
+}
+
+// NewJSONLogger returns a new JSONLogger writing to w.
+func NewJSONLogger(w io.Writer, opts ...LoggerOption) *JSONLogger {
+	l := &JSONLogger{writerLogger: writerLogger{opts: evaluateLoggerOptions(opts), w: w}}
+	l.appendEvent = appendJSONEvent
+	return l
+}
+
+// Log implements Logger.
+func (l *JSONLogger) Log(_ context.Context, level Level, msg string, fields ...any) {
+	l.log(level, msg, fields)
+}
+
+func appendJSONEvent(buf []byte, t string, level Level, msg string, fields Fields) []byte {
+	buf = append(buf, '{')
+	if t != "" {
+		buf = append(buf, `"time":`...)
+		buf = appendQuoted(buf, t)
+		buf = append(buf, ',')
+	}
+	buf = append(buf, `"level":`...)
+	buf = appendQuoted(buf, level.SlogLevel().String())
+	buf = append(buf, `,"msg":`...)
+	buf = appendQuoted(buf, msg)
+	i := fields.Iterator()
+	for i.Next() {
+		k, v := i.At()
+		buf = append(buf, ',')
+		buf = appendQuoted(buf, k)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, v)
+	}
+	return append(buf, '}')
+}
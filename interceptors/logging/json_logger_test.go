@@ -0,0 +1,117 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJSONLogger(t *testing.T) {
+	type custom struct {
+		A int `json:"a"`
+	}
+	for _, tc := range []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "string", value: "value", want: `"value"`},
+		{name: "quotes", value: `say "hi" \o/`, want: `"say \"hi\" \\o/"`},
+		{name: "control characters", value: "a\x00b\x1f\n\r\t\x7f", want: `"a\u0000b\u001f\n\r\t\u007f"`},
+		{name: "line separators", value: "a\u2028b\u2029", want: `"a\u2028b\u2029"`},
+		{name: "invalid utf8", value: "a\xffb", want: "\"a\ufffdb\""},
+		{name: "int", value: 42, want: `42`},
+		{name: "uint64", value: uint64(math.MaxUint64), want: `18446744073709551615`},
+		{name: "float", value: 1.5, want: `1.5`},
+		{name: "nan", value: math.NaN(), want: `"NaN"`},
+		{name: "bool", value: true, want: `true`},
+		{name: "nil", value: nil, want: `null`},
+		{name: "duration", value: 1500 * time.Millisecond, want: `"1.5s"`},
+		{name: "error", value: errors.New(`failed: "boom"`), want: `"failed: \"boom\""`},
+		{name: "time", value: time.Date(2023, 11, 10, 23, 0, 0, 0, time.UTC), want: `"2023-11-10T23:00:00Z"`},
+		{name: "struct", value: custom{A: 1}, want: `{"a":1}`},
+		{name: "raw json", value: json.RawMessage("{\"a\": [1, 2]}\n"), want: `{"a":[1,2]}`},
+		{name: "invalid raw json", value: json.RawMessage(`{"a"`), want: `"{\"a\""`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			NewJSONLogger(&buf, WithLoggerTimeFormat("")).Log(context.Background(), LevelWarn, "msg\n", "key", tc.value)
+
+			want := `{"level":"WARN","msg":"msg\n","key":` + tc.want + "}\n"
+			if got := buf.String(); got != want {
+				t.Fatalf("got %s, want %s", got, want)
+			}
+			if !json.Valid(buf.Bytes()) {
+				t.Fatalf("%s is not valid JSON", buf.String())
+			}
+		})
+	}
+}
+
+func TestJSONLoggerTime(t *testing.T) {
+	var buf bytes.Buffer
+	NewJSONLogger(&buf).Log(context.Background(), LevelInfo, "msg")
+
+	var event map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, event["time"].(string)); err != nil {
+		t.Fatalf("time: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"time":`) {
+		t.Fatalf("got %s, want the time first", buf.String())
+	}
+}
+
+func TestJSONLoggerMinLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewJSONLogger(&buf, WithMinLevel(LevelWarn), WithLoggerTimeFormat(""))
+	ctx := context.Background()
+
+	if l.Enabled(ctx, LevelInfo) || !l.Enabled(ctx, LevelWarn) || !l.Enabled(ctx, LevelError) {
+		t.Fatal("want levels enabled from LevelWarn")
+	}
+	l.Log(ctx, LevelInfo, "info")
+	l.Log(ctx, LevelError, "error")
+	if got, want := buf.String(), `{"level":"ERROR","msg":"error"}`+"\n"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestJSONLoggerConcurrent(t *testing.T) {
+	const goroutines, events = 8, 200
+	var buf bytes.Buffer
+	l := NewJSONLogger(&buf)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < events; i++ {
+				l.Log(context.Background(), LevelInfo, "event", "goroutine", g, "i", i, "payload", strings.Repeat("x", 600))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != goroutines*events {
+		t.Fatalf("got %d lines, want %d", len(lines), goroutines*events)
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("interleaved line: %s", line)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+var (
+	_ Logger       = &LogfmtLogger{}
+	_ LevelEnabler = &LogfmtLogger{}
+)
+
+// LogfmtLogger is a Logger writing events in logfmt, one per line, e.g.
+//
+//	time=2023-11-10T23:00:00Z level=INFO msg="finished call" component=server code=ok
+//
+// Fields are written in order, after time, level and msg. Values containing spaces, quotes, equal signs or
+// control characters are quoted. It is safe for concurrent use.
+type LogfmtLogger struct {
+	writerLogger
+}
+
+// NewLogfmtLogger returns a new LogfmtLogger writing to w.
+func NewLogfmtLogger(w io.Writer, opts ...LoggerOption) *LogfmtLogger {
+	l := &LogfmtLogger{writerLogger: writerLogger{opts: evaluateLoggerOptions(opts), w: w}}
+	l.appendEvent = appendLogfmtEvent
+	return l
+}
+
+// Log implements Logger.
+func (l *LogfmtLogger) Log(_ context.Context, level Level, msg string, fields ...any) {
+	l.log(level, msg, fields)
+}
+
+func appendLogfmtEvent(buf []byte, t string, level Level, msg string, fields Fields) []byte {
+	if t != "" {
+		buf = append(buf, "time="...)
+		buf = appendLogfmtString(buf, t)
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, "level="...)
+	buf = append(buf, level.SlogLevel().String()...)
+	buf = append(buf, " msg="...)
+	buf = appendLogfmtString(buf, msg)
+	i := fields.Iterator()
+	for i.Next() {
+		k, v := i.At()
+		buf = append(buf, ' ')
+		buf = appendLogfmtKey(buf, k)
+		buf = append(buf, '=')
+		buf = appendLogfmtValue(buf, v)
+	}
+	return buf
+}
+
+// appendLogfmtKey appends k, with characters not allowed in logfmt keys replaced by underscores.
+func appendLogfmtKey(buf []byte, k string) []byte {
+	if k == "" {
+		return append(buf, '_')
+	}
+	for _, r := range k {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			r = '_'
+		}
+		buf = utf8.AppendRune(buf, r)
+	}
+	return buf
+}
+
+func appendLogfmtValue(buf []byte, v any) []byte {
+	if v == nil {
+		return append(buf, "null"...)
+	}
+	if b, ok := appendNumber(buf, v); ok {
+		return b
+	}
+	if s, ok := textValue(v); ok {
+		return appendLogfmtString(buf, s)
+	}
+	return appendLogfmtString(buf, fmt.Sprintf("%+v", v))
+}
+
+// appendLogfmtString appends s, quoted if needed.
+func appendLogfmtString(buf []byte, s string) []byte {
+	if needsQuoting(s) {
+		return appendQuoted(buf, s)
+	}
+	return append(buf, s...)
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogfmtLogger(t *testing.T) {
+	type custom struct{ A int }
+	for _, tc := range []struct {
+		name  string
+		key   string
+		value any
+		want  string
+	}{
+		{name: "string", key: "key", value: "value", want: `key=value`},
+		{name: "empty", key: "key", value: "", want: `key=""`},
+		{name: "spaces", key: "key", value: "a b", want: `key="a b"`},
+		{name: "quotes", key: "key", value: `say "hi"`, want: `key="say \"hi\""`},
+		{name: "equal sign", key: "key", value: "a=b", want: `key="a=b"`},
+		{name: "backslash", key: "key", value: `a\b`, want: `key="a\\b"`},
+		{name: "control characters", key: "key", value: "a\x00b\n\x7f", want: `key="a\u0000b\n\u007f"`},
+		{name: "invalid utf8", key: "key", value: "a\xffb", want: "key=\"a\ufffdb\""},
+		{name: "unicode", key: "key", value: "héllo", want: "key=héllo"},
+		{name: "int", key: "key", value: -42, want: `key=-42`},
+		{name: "float", key: "key", value: 0.25, want: `key=0.25`},
+		{name: "infinity", key: "key", value: math.Inf(1), want: `key=+Inf`},
+		{name: "bool", key: "key", value: false, want: `key=false`},
+		{name: "nil", key: "key", value: nil, want: `key=null`},
+		{name: "duration", key: "key", value: 1500 * time.Millisecond, want: `key=1.5s`},
+		{name: "error", key: "key", value: errors.New("connection refused"), want: `key="connection refused"`},
+		{name: "struct", key: "key", value: custom{A: 1}, want: `key={A:1}`},
+		{name: "raw json", key: "key", value: json.RawMessage(`{"a":1}`), want: `key="{\"a\":1}"`},
+		{name: "key with spaces and quotes", key: `a "b"=c`, value: 1, want: `a__b__c=1`},
+		{name: "empty key", key: "", value: 1, want: `_=1`},
+		{name: "key with invalid utf8", key: "a\xffb", value: 1, want: `a_b=1`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			NewLogfmtLogger(&buf, WithLoggerTimeFormat("")).Log(context.Background(), LevelInfo, "finished call", tc.key, tc.value)
+
+			want := `level=INFO msg="finished call" ` + tc.want + "\n"
+			if got := buf.String(); got != want {
+				t.Fatalf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestLogfmtLoggerTime(t *testing.T) {
+	var buf bytes.Buffer
+	NewLogfmtLogger(&buf, WithLoggerTimeFormat(time.RFC3339)).Log(context.Background(), LevelInfo, "msg")
+
+	got := buf.String()
+	if !strings.HasPrefix(got, "time=") || !strings.HasSuffix(got, " level=INFO msg=msg\n") {
+		t.Fatalf("got %s", got)
+	}
+	if _, err := time.Parse(time.RFC3339, strings.TrimPrefix(strings.Fields(got)[0], "time=")); err != nil {
+		t.Fatalf("time: %v", err)
+	}
+}
+
+func TestLogfmtLoggerMinLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogfmtLogger(&buf, WithMinLevel(LevelDebug), WithLoggerTimeFormat(""))
+	ctx := context.Background()
+
+	if l.Enabled(ctx, LevelDebug-1) || !l.Enabled(ctx, LevelDebug) {
+		t.Fatal("want levels enabled from LevelDebug")
+	}
+	l.Log(ctx, LevelDebug-1, "trace")
+	l.Log(ctx, LevelDebug, "debug")
+	if got, want := buf.String(), "level=DEBUG msg=debug\n"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestLogfmtLoggerConcurrent(t *testing.T) {
+	const goroutines, events = 8, 200
+	var buf bytes.Buffer
+	l := NewLogfmtLogger(&buf, WithLoggerTimeFormat(""))
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < events; i++ {
+				l.Log(context.Background(), LevelInfo, "event", "payload", strings.Repeat("x", 600))
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := "level=INFO msg=event payload=" + strings.Repeat("x", 600)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != goroutines*events {
+		t.Fatalf("got %d lines, want %d", len(lines), goroutines*events)
+	}
+	for _, line := range lines {
+		if line != want {
+			t.Fatalf("interleaved line: %s", line)
+		}
+	}
+}
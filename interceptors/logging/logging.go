@@ -5,6 +5,10 @@ package logging
 
 import (
 	"context"
+	"fmt"
+	"sync"
+
+	"connectrpc.com/connect"
 
 	"github.com/svrana/go-connect-middleware/interceptors"
 )
@@ -38,6 +42,39 @@ func newCommonFields(kind string, c interceptors.CallMeta) Fields {
 	}
 }
 
+type commonFieldsKey struct {
+	kind, service, method string
+	typ                   connect.StreamType
+}
+
+// commonFieldsCache holds the common fields of each procedure, so that they are built once rather than on every call.
+// Cached fields are never modified.
+type commonFieldsCache struct {
+	mu     sync.RWMutex
+	fields map[commonFieldsKey]Fields
+}
+
+func (c *commonFieldsCache) get(kind string, meta interceptors.CallMeta) Fields {
+	key := commonFieldsKey{kind: kind, service: meta.Service, method: meta.Method, typ: meta.Typ}
+	c.mu.RLock()
+	f, ok := c.fields[key]
+	c.mu.RUnlock()
+	if ok {
+		return f
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f, ok = c.fields[key]; !ok {
+		if c.fields == nil {
+			c.fields = map[commonFieldsKey]Fields{}
+		}
+		f = newCommonFields(kind, meta)
+		c.fields[key] = f
+	}
+	return f
+}
+
 // Fields loosely represents key value pairs that adds context to log lines. The key has to be type of string, whereas
 // value can be an arbitrary object.
 type Fields []any
@@ -76,50 +113,60 @@ func (i *iter) At() (k string, v any) {
 	return i.f[i.i].(string), i.f[i.i+1]
 }
 
-// WithUnique returns copy of fields which is the union of all unique keys.
-// Any duplicates in the added or current fields will be deduplicated where first occurrence takes precedence.
-func (f Fields) WithUnique(add Fields) Fields {
-	if len(add) == 0 {
-		n := make(Fields, len(f), len(f))
-		copy(n, f)
-		return n
+// at is like iter.At for the field at index i, but returns the key as is rather than as a string, which saves
+// boxing it again when appended to fields.
+func (f Fields) at(i int) (k, v any) {
+	if _, ok := f[i].(string); !ok {
+		panic(fmt.Sprintf("logging: field key %v is not a string", f[i]))
 	}
-
-	existing := map[any]struct{}{}
-	i := f.Iterator()
-	for i.Next() {
-		k, _ := i.At()
-		existing[k] = struct{}{}
+	if i+1 == len(f) {
+		// Non even number of elements, add empty string.
+		return f[i], ""
 	}
+	return f[i], f[i+1]
+}
 
-	n := make(Fields, len(f), len(f)+len(add))
+// WithUnique returns copy of fields which is the union of all unique keys.
+// Any duplicates in the added or current fields will be deduplicated where first occurrence takes precedence.
+func (f Fields) WithUnique(add Fields) Fields {
+	n := make(Fields, len(f), len(f)+len(add)+len(add)%2)
 	copy(n, f)
-
-	a := add.Iterator()
-	for a.Next() {
-		k, v := a.At()
-		if _, ok := existing[k]; ok {
-			continue
-		}
-		n = append(n, k, v)
-	}
-	return n
+	// Keys are only looked up in f: keys repeated within add are all kept.
+	return n.appendUnique(add, len(f))
 }
 
+// uniqueScanLimit is the number of key comparisons above which keys are indexed in a map rather than scanned, which is
+// faster for the handful of fields usually found in a call.
+const uniqueScanLimit = 256
+
 // AppendUnique appends (can reuse array!) fields which does not occur in existing fields slice.
 func (f Fields) AppendUnique(add Fields) Fields {
 	if len(add) == 0 {
 		return f
 	}
+	if free := cap(f) - len(f); free < len(add)+len(add)%2 {
+		n := make(Fields, len(f), len(f)+len(add)+len(add)%2)
+		copy(n, f)
+		f = n
+	}
+	return f.appendUnique(add, -1)
+}
 
-	a := add.Iterator()
+// appendUnique appends the fields of add whose key isn't among the first known fields of f, or among all the fields
+// of f (including the ones appended) if known is negative.
+func (f Fields) appendUnique(add Fields, known int) Fields {
+	if (len(f)/2+1)*(len(add)/2+1) > uniqueScanLimit {
+		return f.appendUniqueIndexed(add, known)
+	}
 NextAddField:
-	for a.Next() {
-		k, v := a.At()
-		i := f.Iterator()
-		for i.Next() {
-			fk, _ := i.At()
-			if fk == k {
+	for i := 0; i < len(add); i += 2 {
+		k, v := add.at(i)
+		keys := f
+		if known >= 0 {
+			keys = f[:known]
+		}
+		for j := 0; j < len(keys); j += 2 {
+			if keys[j] == k {
 				continue NextAddField
 			}
 		}
@@ -128,17 +175,68 @@ NextAddField:
 	return f
 }
 
+func (f Fields) appendUniqueIndexed(add Fields, known int) Fields {
+	keys := f
+	if known >= 0 {
+		keys = f[:known]
+	}
+	existing := make(map[any]struct{}, (len(keys)+len(add))/2+1)
+	for j := 0; j < len(keys); j += 2 {
+		existing[keys[j]] = struct{}{}
+	}
+	for i := 0; i < len(add); i += 2 {
+		k, v := add.at(i)
+		if _, ok := existing[k]; ok {
+			continue
+		}
+		if known < 0 {
+			existing[k] = struct{}{}
+		}
+		f = append(f, k, v)
+	}
+	return f
+}
+
+// fieldsContext is the context holding the fields of InjectFields. Fields are deduplicated and never modified once
+// in a context, so they are shared between nested contexts and with the reporter.
+type fieldsContext struct {
+	context.Context
+	fields Fields
+	// logger is the Logger of the logging interceptor handling the call, if any. See FromContext.
+	logger Logger
+}
+
+func (c *fieldsContext) Value(key any) any {
+	if key == fieldsCtxMarkerKey {
+		return c
+	}
+	return c.Context.Value(key)
+}
+
+func fieldsContextOf(ctx context.Context) *fieldsContext {
+	c, _ := ctx.Value(fieldsCtxMarkerKey).(*fieldsContext)
+	return c
+}
+
+func fieldsFromContext(ctx context.Context) Fields {
+	if c := fieldsContextOf(ctx); c != nil {
+		return c.fields
+	}
+	return nil
+}
+
 // ExtractFields returns logging fields from the context.
 // Logging interceptor adds fields into context when used.
 // If there are no fields in the context, returns an empty Fields value.
 // Extracted fields are useful to construct your own logger that has fields from gRPC interceptors.
+// The returned fields are a copy, owned by the caller.
 func ExtractFields(ctx context.Context) Fields {
-	t, ok := ctx.Value(fieldsCtxMarkerKey).(Fields)
-	if !ok {
+	f := fieldsFromContext(ctx)
+	if f == nil {
 		return nil
 	}
-	n := make(Fields, len(t))
-	copy(n, t)
+	n := make(Fields, len(f))
+	copy(n, f)
 	return n
 }
 
@@ -148,7 +246,13 @@ func ExtractFields(ctx context.Context) Fields {
 // can be overridden if your you add custom middleware that injects "connect.service" before logging middleware injects those.
 // Don't overuse overriding to avoid surprises.
 func InjectFields(ctx context.Context, f Fields) context.Context {
-	return context.WithValue(ctx, fieldsCtxMarkerKey, ExtractFields(ctx).WithUnique(f))
+	var base Fields
+	var logger Logger
+	if c := fieldsContextOf(ctx); c != nil {
+		base, logger = c.fields, c.logger
+	}
+	n := base.WithUnique(f)
+	return &fieldsContext{Context: ctx, fields: n[:len(n):len(n)], logger: logger}
 }
 
 // InjectLogField is like InjectFields, just for one field.
@@ -156,16 +260,84 @@ func InjectLogField(ctx context.Context, key string, val any) context.Context {
 	return InjectFields(ctx, Fields{key, val})
 }
 
-// Logger requires Log method, similar to experimental slog, allowing logging interceptor to be interoperable. Official
-// adapters for popular loggers are in `provider/` directory (separate modules). It's totally ok to copy simple function
-// implementation over.
-// TODO(bwplotka): Once slog is official, we could use slog method directly. Currently level is copied over, so we don't
-// depend on experimental module.
-// interface used for all our interceptors.
+// FromContext returns a Logger logging with the Logger of the logging interceptor handling the call, adding the fields
+// of ctx (see ExtractFields): service, method and any injected field. This lets handlers log with the fields
+// of the interceptor without passing a logger around. Fields given to Log follow the fields of ctx, and take
+// precedence over the fields of ctx with the same key, e.g. a handler may log its own "method" field. If no logging
+// interceptor handles the call, the returned Logger discards everything.
+//
+// Loggers reporting the caller of Log report this package rather than the handler.
+func FromContext(ctx context.Context) Logger {
+	c := fieldsContextOf(ctx)
+	if c == nil || c.logger == nil {
+		return nopLogger{}
+	}
+	return contextLogger{logger: c.logger, fields: c.fields}
+}
+
+// Log logs with the Logger of the logging interceptor handling the call and the fields of ctx. See FromContext.
+func Log(ctx context.Context, level Level, msg string, fields ...any) {
+	if c := fieldsContextOf(ctx); c != nil && c.logger != nil {
+		contextLogger{logger: c.logger, fields: c.fields}.Log(ctx, level, msg, fields...)
+	}
+}
+
+// contextLogger is the Logger returned by FromContext.
+type contextLogger struct {
+	logger Logger
+	fields Fields
+}
+
+func (l contextLogger) Log(ctx context.Context, level Level, msg string, fields ...any) {
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	l.logger.Log(ctx, level, msg, l.withFields(fields)...)
+}
+
+// withFields returns the fields of the logger without the ones whose key is in fields, followed by fields.
+func (l contextLogger) withFields(fields Fields) Fields {
+	if len(fields) == 0 {
+		return l.fields
+	}
+	n := make(Fields, 0, len(l.fields)+len(fields)+len(fields)%2)
+NextField:
+	for i := 0; i < len(l.fields); i += 2 {
+		for j := 0; j < len(fields); j += 2 {
+			if fields[j] == l.fields[i] {
+				continue NextField
+			}
+		}
+		n = append(n, l.fields[i], l.fields[i+1])
+	}
+	return n.AppendUnique(fields)
+}
+
+func (l contextLogger) Enabled(ctx context.Context, level Level) bool {
+	e, ok := l.logger.(LevelEnabler)
+	return !ok || e.Enabled(ctx, level)
+}
+
+// nopLogger is the Logger returned by FromContext outside of logging interceptors.
+type nopLogger struct{}
+
+func (nopLogger) Log(context.Context, Level, string, ...any) {}
+
+func (nopLogger) Enabled(context.Context, Level) bool { return false }
+
+// Logger requires Log method, similar to slog, allowing logging interceptor to be interoperable. Use SlogLogger for a
+// log/slog logger, adapters for zap, zerolog, logrus and logr are in the `adapters/` directory. It's totally ok to copy
+// simple function implementation over.
 type Logger interface {
 	Log(ctx context.Context, level Level, msg string, fields ...any)
 }
 
+// LevelEnabler is optionally implemented by Loggers able to tell in advance whether they discard events of a level.
+// The interceptors check it before building fields, so that discarded events cost almost nothing.
+type LevelEnabler interface {
+	Enabled(ctx context.Context, level Level) bool
+}
+
 // LoggerFunc is a function that also implements Logger interface.
 type LoggerFunc func(ctx context.Context, level Level, msg string, fields ...any)
 
@@ -0,0 +1,227 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+func TestFieldsAt(t *testing.T) {
+	f := Fields{"a", 1, "b"}
+	if k, v := f.at(0); k != "a" || v != 1 {
+		t.Fatalf("at(0) = %v, %v", k, v)
+	}
+	if k, v := f.at(2); k != "b" || v != "" {
+		t.Fatalf("at(2) = %v, %v, want the value of a trailing key to be empty", k, v)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("want a panic for a key which isn't a string")
+		}
+	}()
+	Fields{1, "a"}.at(0)
+}
+
+func TestWithUnique(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		f, add Fields
+		want   Fields
+	}{
+		{name: "empty", f: nil, add: Fields{"a", 1}, want: Fields{"a", 1}},
+		{name: "nothing to add", f: Fields{"a", 1}, add: nil, want: Fields{"a", 1}},
+		{name: "distinct keys", f: Fields{"a", 1}, add: Fields{"b", 2}, want: Fields{"a", 1, "b", 2}},
+		{name: "first occurrence wins", f: Fields{"a", 1, "b", 2}, add: Fields{"b", 3, "c", 4}, want: Fields{"a", 1, "b", 2, "c", 4}},
+		{name: "duplicates within add are kept", f: Fields{"a", 1}, add: Fields{"b", 2, "b", 3}, want: Fields{"a", 1, "b", 2, "b", 3}},
+		{name: "odd length", f: Fields{"a", 1}, add: Fields{"b", 2, "c"}, want: Fields{"a", 1, "b", 2, "c", ""}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := append(make(Fields, 0, len(tc.f)+10), tc.f...)
+			got := f.WithUnique(tc.add)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			if got := f[:cap(f)][len(f):]; len(tc.add) > 0 && got[0] != nil {
+				t.Fatalf("WithUnique wrote to the array of f: %v", got)
+			}
+		})
+	}
+}
+
+func TestAppendUnique(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		f, add Fields
+		want   Fields
+	}{
+		{name: "empty", f: nil, add: Fields{"a", 1}, want: Fields{"a", 1}},
+		{name: "nothing to add", f: Fields{"a", 1}, add: nil, want: Fields{"a", 1}},
+		{name: "distinct keys", f: Fields{"a", 1}, add: Fields{"b", 2}, want: Fields{"a", 1, "b", 2}},
+		{name: "first occurrence wins", f: Fields{"a", 1, "b", 2}, add: Fields{"b", 3, "c", 4}, want: Fields{"a", 1, "b", 2, "c", 4}},
+		{name: "duplicates within add are dropped", f: Fields{"a", 1}, add: Fields{"b", 2, "b", 3}, want: Fields{"a", 1, "b", 2}},
+		{name: "odd length", f: Fields{"a", 1}, add: Fields{"b", 2, "c"}, want: Fields{"a", 1, "b", 2, "c", ""}},
+		{name: "odd length duplicate", f: Fields{"a", 1}, add: Fields{"a"}, want: Fields{"a", 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, spare := range []int{0, 10} {
+				f := append(make(Fields, 0, len(tc.f)+spare), tc.f...)
+				if got := f.AppendUnique(tc.add); !reflect.DeepEqual(got, tc.want) {
+					t.Fatalf("with %d spare capacity: got %v, want %v", spare, got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+// naiveUnique is the reference of appendUnique: fields of add are appended if their key isn't among the first known
+// fields of f, or among all the fields of f if known is negative.
+func naiveUnique(f, add Fields, known int) Fields {
+	f = append(Fields(nil), f...)
+	for i := 0; i < len(add); i += 2 {
+		keys := f
+		if known >= 0 {
+			keys = f[:known]
+		}
+		dup := false
+		for j := 0; j < len(keys); j += 2 {
+			dup = dup || keys[j] == add[i]
+		}
+		if dup {
+			continue
+		}
+		if i+1 < len(add) {
+			f = append(f, add[i], add[i+1])
+		} else {
+			f = append(f, add[i], "")
+		}
+	}
+	return f
+}
+
+func TestAppendUniqueIndexed(t *testing.T) {
+	fields := func(n, mod int, prefix string) Fields {
+		f := make(Fields, 0, 2*n)
+		for i := 0; i < n; i++ {
+			f = append(f, fmt.Sprintf("key%d", i%mod), prefix+fmt.Sprint(i))
+		}
+		return f
+	}
+	for _, tc := range []struct {
+		name   string
+		f, add Fields
+	}{
+		{name: "small", f: fields(4, 4, "f"), add: fields(6, 3, "add")},
+		// Above uniqueScanLimit, keys are indexed in a map.
+		{name: "large", f: fields(40, 30, "f"), add: fields(40, 50, "add")},
+		{name: "large with odd add", f: fields(40, 40, "f"), add: append(fields(40, 60, "add"), "key59")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, known := range []int{-1, len(tc.f)} {
+				want := naiveUnique(tc.f, tc.add, known)
+				f := append(Fields(nil), tc.f...)
+				if got := f.appendUnique(tc.add, known); !reflect.DeepEqual(got, want) {
+					t.Fatalf("appendUnique(known=%d) = %v, want %v", known, got, want)
+				}
+				f = append(Fields(nil), tc.f...)
+				if got := f.appendUniqueIndexed(tc.add, known); !reflect.DeepEqual(got, want) {
+					t.Fatalf("appendUniqueIndexed(known=%d) = %v, want %v", known, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInjectExtractFields(t *testing.T) {
+	ctx := context.Background()
+	if got := ExtractFields(ctx); got != nil {
+		t.Fatalf("got %v, want no fields", got)
+	}
+
+	outer := InjectFields(ctx, Fields{"a", 1, "b", 2})
+	inner := InjectFields(outer, Fields{"b", 3, "c", 4})
+	inner = InjectLogField(inner, "d", 5)
+	if got, want := ExtractFields(inner), (Fields{"a", 1, "b", 2, "c", 4, "d", 5}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("inner fields = %v, want %v", got, want)
+	}
+	if got, want := ExtractFields(outer), (Fields{"a", 1, "b", 2}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("outer fields = %v, want %v", got, want)
+	}
+
+	// Extracted fields are owned by the caller.
+	f := ExtractFields(outer)
+	f[1] = "modified"
+	_ = append(f[:2], "x", "y")
+	if got, want := ExtractFields(outer), (Fields{"a", 1, "b", 2}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("outer fields = %v after modifying extracted fields, want %v", got, want)
+	}
+	if got, want := ExtractFields(inner), (Fields{"a", 1, "b", 2, "c", 4, "d", 5}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("inner fields = %v after modifying extracted fields, want %v", got, want)
+	}
+}
+
+type logEntry struct {
+	level  Level
+	msg    string
+	fields Fields
+}
+
+// entriesLogger records its entries, and is only enabled from minLevel.
+type entriesLogger struct {
+	minLevel Level
+	entries  []logEntry
+}
+
+func (l *entriesLogger) Log(_ context.Context, level Level, msg string, fields ...any) {
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, fields: fields})
+}
+
+func (l *entriesLogger) Enabled(_ context.Context, level Level) bool {
+	return level >= l.minLevel
+}
+
+func TestFromContextOutsideInterceptor(t *testing.T) {
+	ctx := InjectFields(context.Background(), Fields{"user", "bob"})
+
+	logger := FromContext(ctx)
+	if _, ok := logger.(nopLogger); !ok {
+		t.Fatalf("got %T, want a Logger discarding everything", logger)
+	}
+	if logger.(LevelEnabler).Enabled(ctx, LevelError) {
+		t.Fatal("want every level disabled")
+	}
+	logger.Log(ctx, LevelError, "msg", "key", "value")
+	Log(ctx, LevelError, "msg", "key", "value")
+	Log(context.Background(), LevelError, "msg")
+}
+
+func TestFromContext(t *testing.T) {
+	logger := &entriesLogger{minLevel: LevelInfo}
+	meta := interceptors.CallMeta{Service: "ping.v1.PingService", Method: "Ping", Typ: connect.StreamTypeUnary}
+	_, ctx := reportable(logger, evaluateServerOpt(nil))(InjectFields(context.Background(), Fields{"user", "bob"}), meta)
+	ctx = InjectLogField(ctx, "tenant", "acme")
+
+	FromContext(ctx).Log(ctx, LevelInfo, "from logger")
+	Log(ctx, LevelWarn, "explicit fields", "method", "Other", "extra", 1, "extra", 2, "odd")
+	Log(ctx, LevelDebug, "disabled")
+	if !FromContext(ctx).(LevelEnabler).Enabled(ctx, LevelInfo) || FromContext(ctx).(LevelEnabler).Enabled(ctx, LevelDebug) {
+		t.Fatal("want the levels of the interceptor's Logger")
+	}
+
+	common := Fields{"user", "bob", "component", "server", "service", "ping.v1.PingService"}
+	want := []logEntry{
+		{level: LevelInfo, msg: "from logger", fields: append(common, "method", "Ping", "method_type", connect.StreamTypeUnary, "tenant", "acme")},
+		{level: LevelWarn, msg: "explicit fields", fields: append(common, "method_type", connect.StreamTypeUnary, "tenant", "acme", "method", "Other", "extra", 1, "odd", "")},
+	}
+	if !reflect.DeepEqual(logger.entries, want) {
+		t.Fatalf("got %v, want %v", logger.entries, want)
+	}
+}
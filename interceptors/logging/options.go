@@ -5,10 +5,11 @@ package logging
 
 import (
 	"context"
-	"fmt"
+	"strconv"
 	"time"
 
 	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 // LoggableEvent defines the events a log line can be added on.
@@ -20,13 +21,15 @@ const (
 	// FinishCall is a loggable event representing finish of the connect call.
 	FinishCall
 	// PayloadReceived is a loggable event representing received request (server) or response (client).
-	// Log line for this event also includes (potentially big) proto.Message of that payload in
-	// "grpc.request.content" (server) or "grpc.response.content" (client) field.
+	// Log line for this event also includes (potentially big) proto.Message of that payload, rendered with protojson,
+	// in "request.content" (server) or "response.content" (client) field, a json.RawMessage unless truncated. See
+	// WithPayloadMarshalOptions and WithMaxPayloadSize. Each message of streams is logged.
 	// NOTE: This can get quite verbose, especially for streaming calls, use with caution (e.g. debug only purposes).
 	PayloadReceived
 	// PayloadSent is a loggable event representing sent response (server) or request (client).
-	// Log line for this event also includes (potentially big) proto.Message of that payload in
-	// "grpc.response.content" (server) or "grpc.request.content" (client) field.
+	// Log line for this event also includes (potentially big) proto.Message of that payload, rendered with protojson,
+	// in "response.content" (server) or "request.content" (client) field, a json.RawMessage unless truncated. See
+	// WithPayloadMarshalOptions and WithMaxPayloadSize. Each message of streams is logged.
 	// NOTE: This can get quite verbose, especially for streaming calls, use with caution (e.g. debug only purposes).
 	PayloadSent
 )
@@ -48,6 +51,8 @@ var (
 		// levelFunc depends if it's client or server.
 		levelFunc:       nil,
 		timestampFormat: time.RFC3339,
+		maxPayloadSize:  DefaultMaxPayloadSize,
+		redactedHeaders: DefaultRedactedHeaders,
 	}
 )
 
@@ -58,6 +63,18 @@ type options struct {
 	durationFieldFunc DurationToFields
 	timestampFormat   string
 	fieldsFromCtxFn   fieldsFromCtxFn
+	traceContextFn    TraceContextFunc
+	traceFieldKeys    *TraceFieldKeys
+
+	payloadMarshalOptions protojson.MarshalOptions
+	maxPayloadSize        int
+	redactedFieldPaths    []string
+	redactedFieldNames    []string
+	redactedHeaders       []string
+	requestHeaders        *HeaderFields
+	responseHeaders       *HeaderFields
+	responseTrailers      *HeaderFields
+	procedureOptions      []procedureOptions
 }
 
 type Option func(*options)
@@ -90,6 +107,10 @@ type DurationToFields func(duration time.Duration) Fields
 type ErrorToCode func(err error) connect.Code
 
 func DefaultErrorToCode(err error) connect.Code {
+	// connect.CodeOf allocates to unwrap errors, which most errors returned by connect don't need.
+	if connectErr, ok := err.(*connect.Error); ok {
+		return connectErr.Code()
+	}
 	return connect.CodeOf(err)
 }
 
@@ -138,6 +159,72 @@ func WithFieldsFromContext(f fieldsFromCtxFn) Option {
 	}
 }
 
+// TraceContextFunc returns the hex encoded trace and span IDs of the span found in the context, and whether it is
+// sampled. An empty trace ID means there is no span. See tracing.TraceContext for OpenTelemetry spans.
+type TraceContextFunc func(ctx context.Context) (traceID, spanID string, sampled bool)
+
+// TraceFieldKeys are the keys of the fields correlating log lines with traces. An empty key omits the field.
+type TraceFieldKeys struct {
+	TraceID string
+	SpanID  string
+	Sampled string
+	// FormatTraceID formats the hex encoded trace ID. Defaults to the hex encoding itself.
+	FormatTraceID func(string) string
+	// FormatSpanID formats the hex encoded span ID. Defaults to the hex encoding itself.
+	FormatSpanID func(string) string
+}
+
+var (
+	// DefaultTraceFieldKeys are the default trace correlation fields, holding hex encoded IDs.
+	DefaultTraceFieldKeys = TraceFieldKeys{TraceID: "trace_id", SpanID: "span_id", Sampled: "trace_sampled"}
+
+	// DatadogTraceFieldKeys are the trace correlation fields expected by Datadog, which holds the decimal value of
+	// the lower 64 bits of the IDs.
+	DatadogTraceFieldKeys = TraceFieldKeys{
+		TraceID:       "dd.trace_id",
+		SpanID:        "dd.span_id",
+		FormatTraceID: lower64Decimal,
+		FormatSpanID:  lower64Decimal,
+	}
+)
+
+// lower64Decimal returns the decimal value of the lower 64 bits of the given hex encoded ID.
+func lower64Decimal(id string) string {
+	if len(id) > 16 {
+		id = id[len(id)-16:]
+	}
+	v, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return id
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// CloudLoggingTraceFieldKeys returns the trace correlation fields expected by Google Cloud Logging for traces
+// of the given project.
+func CloudLoggingTraceFieldKeys(projectID string) TraceFieldKeys {
+	return TraceFieldKeys{
+		TraceID: "logging.googleapis.com/trace",
+		SpanID:  "logging.googleapis.com/spanId",
+		Sampled: "logging.googleapis.com/trace_sampled",
+		FormatTraceID: func(id string) string {
+			return "projects/" + projectID + "/traces/" + id
+		},
+	}
+}
+
+// WithTraceFields adds the trace ID, span ID and sampled flag of the span returned by the given function, if any,
+// to all log messages of the call. For OpenTelemetry spans, use tracing.TraceContext and place the tracing
+// interceptor before the logging interceptor for the span of the call to be found:
+//
+//	logging.WithTraceFields(tracing.TraceContext, logging.DefaultTraceFieldKeys)
+func WithTraceFields(f TraceContextFunc, keys TraceFieldKeys) Option {
+	return func(o *options) {
+		o.traceContextFn = f
+		o.traceFieldKeys = &keys
+	}
+}
+
 // WithLogOnEvents customizes on what events the gRPC interceptor should log on.
 func WithLogOnEvents(events ...LoggableEvent) Option {
 	return func(o *options) {
@@ -171,7 +258,7 @@ var DefaultDurationToFields = DurationToTimeMillisFields
 
 // DurationToTimeMillisFields converts the duration to milliseconds and uses the key `grpc.time_ms`.
 func DurationToTimeMillisFields(duration time.Duration) Fields {
-	return Fields{"time_ms", fmt.Sprintf("%v", durationToMilliseconds(duration))}
+	return Fields{"time_ms", strconv.FormatFloat(float64(durationToMilliseconds(duration)), 'g', -1, 32)}
 }
 
 // DurationToDurationField uses a Duration field to log the request duration
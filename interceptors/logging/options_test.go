@@ -0,0 +1,58 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTraceFields(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	span := func(context.Context) (string, string, bool) { return traceID, spanID, true }
+	for _, tc := range []struct {
+		name string
+		f    TraceContextFunc
+		keys TraceFieldKeys
+		want Fields
+	}{
+		{
+			name: "default",
+			f:    span,
+			keys: DefaultTraceFieldKeys,
+			want: Fields{"trace_id", traceID, "span_id", spanID, "trace_sampled", true},
+		},
+		{
+			name: "datadog",
+			f:    span,
+			keys: DatadogTraceFieldKeys,
+			want: Fields{"dd.trace_id", "11803532876627986230", "dd.span_id", "67667974448284343"},
+		},
+		{
+			name: "cloud logging",
+			f:    span,
+			keys: CloudLoggingTraceFieldKeys("project"),
+			want: Fields{
+				"logging.googleapis.com/trace", "projects/project/traces/" + traceID,
+				"logging.googleapis.com/spanId", spanID,
+				"logging.googleapis.com/trace_sampled", true,
+			},
+		},
+		{
+			name: "no span",
+			f:    func(context.Context) (string, string, bool) { return "", "", false },
+			keys: DefaultTraceFieldKeys,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := traceFields(context.Background(), tc.f, &tc.keys); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultMaxPayloadSize is the default maximum size, in bytes, of logged payloads.
+const DefaultMaxPayloadSize = 4 << 10
+
+// payloadValue returns the value of the field logging payload, a connect.AnyRequest or connect.AnyResponse: its
+// message rendered with protojson, with its fields to redact redacted (see WithRedactedFields), or the message itself
+// if it's not a proto.Message. The JSON is a json.RawMessage, so that loggers embed it rather than quote it, unless it
+// is truncated, which makes it a string. It returns false if there is no message.
+func (o *options) payloadValue(payload any) (any, bool) {
+	p, ok := payload.(interface{ Any() any })
+	if !ok {
+		return nil, false
+	}
+	msg := p.Any()
+	if msg == nil {
+		return nil, false
+	}
+	pm, ok := msg.(proto.Message)
+	if !ok {
+		return msg, true
+	}
+	b, err := o.payloadMarshalOptions.Marshal(o.redact(pm))
+	if err != nil {
+		// Never fall back to the message, which isn't redacted.
+		return "<unloggable payload: " + err.Error() + ">", true
+	}
+	if o.maxPayloadSize <= 0 || len(b) <= o.maxPayloadSize {
+		return json.RawMessage(b), true
+	}
+	return truncate(string(b), o.maxPayloadSize), true
+}
+
+// truncate returns s cut to at most max bytes (if max is positive), on a rune boundary and followed by a marker
+// telling how many bytes were cut.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "...(truncated " + strconv.Itoa(len(s)-cut) + " bytes)"
+}
+
+// WithPayloadMarshalOptions customizes how the messages logged by the PayloadReceived and PayloadSent events are
+// rendered, e.g. to emit unpopulated fields (EmitUnpopulated) or use proto field names (UseProtoNames).
+func WithPayloadMarshalOptions(mo protojson.MarshalOptions) Option {
+	return func(o *options) {
+		o.payloadMarshalOptions = mo
+	}
+}
+
+// WithMaxPayloadSize customizes the maximum size, in bytes, of the messages logged by the PayloadReceived and
+// PayloadSent events. Longer messages are truncated, marked as such and logged as a string rather than raw JSON.
+// Zero or less doesn't limit the size. Defaults to DefaultMaxPayloadSize.
+func WithMaxPayloadSize(size int) Option {
+	return func(o *options) {
+		o.maxPayloadSize = size
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestTruncate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		s    string
+		max  int
+		want string
+	}{
+		{name: "no limit", s: "hello", max: 0, want: "hello"},
+		{name: "shorter", s: "hello", max: 10, want: "hello"},
+		{name: "exact", s: "hello", max: 5, want: "hello"},
+		{name: "cut", s: "hello", max: 3, want: "hel...(truncated 2 bytes)"},
+		// "é" is 2 bytes, at offsets 1 and 2: cutting at 2 would split it.
+		{name: "rune boundary", s: "héllo", max: 2, want: "h...(truncated 5 bytes)"},
+		{name: "after rune", s: "héllo", max: 3, want: "hé...(truncated 3 bytes)"},
+		{name: "first rune", s: "日本", max: 2, want: "...(truncated 6 bytes)"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := truncate(tc.s, tc.max); got != tc.want {
+				t.Fatalf("truncate(%q, %d) = %q, want %q", tc.s, tc.max, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPayloadValue(t *testing.T) {
+	msg, err := structpb.NewStruct(map[string]any{"name": "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := connect.NewRequest(msg)
+
+	v, ok := evaluateServerOpt(nil).payloadValue(req)
+	if raw, isRaw := v.(json.RawMessage); !ok || !isRaw || !json.Valid(raw) {
+		t.Fatalf("got %T %v, want raw JSON", v, v)
+	}
+
+	v, ok = evaluateServerOpt([]Option{WithMaxPayloadSize(5)}).payloadValue(req)
+	if s, isString := v.(string); !ok || !isString || !strings.HasSuffix(s, "bytes)") {
+		t.Fatalf("got %T %v, want a truncated string", v, v)
+	}
+
+	if v, ok := evaluateServerOpt(nil).payloadValue(connect.NewRequest(&struct{ A int }{A: 1})); !ok || v.(*struct{ A int }).A != 1 {
+		t.Fatalf("got %v, want the message itself", v)
+	}
+	if _, ok := evaluateServerOpt(nil).payloadValue(nil); ok {
+		t.Fatal("want no payload without message")
+	}
+}
+
+// payloadEvents returns the "*.content" fields of the events logged as JSON in buf, by message.
+func payloadEvents(t *testing.T, buf *bytes.Buffer) map[string][]string {
+	events := map[string][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		var msg string
+		_ = json.Unmarshal(e["msg"], &msg)
+		for _, k := range []string{"request.content", "response.content"} {
+			if c, ok := e[k]; ok {
+				events[msg] = append(events[msg], string(c))
+			}
+		}
+	}
+	return events
+}
+
+func TestPayloadLogging(t *testing.T) {
+	const (
+		echoProcedure   = "/test.v1.TestService/Echo"
+		streamProcedure = "/test.v1.TestService/Stream"
+	)
+	var serverLog, clientLog bytes.Buffer
+	opts := []Option{WithLogOnEvents(PayloadReceived, PayloadSent)}
+	serverInterceptors := connect.WithInterceptors(
+		UnaryServerInterceptor(NewJSONLogger(&serverLog), opts...),
+		StreamServerInterceptor(NewJSONLogger(&serverLog), opts...),
+	)
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[structpb.Struct]) (*connect.Response[structpb.Struct], error) {
+		return connect.NewResponse(req.Msg), nil
+	}, serverInterceptors))
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure, func(ctx context.Context, req *connect.Request[structpb.Struct], stream *connect.ServerStream[structpb.Struct]) error {
+		for i := 0; i < 2; i++ {
+			if err := stream.Send(req.Msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, serverInterceptors))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	clientInterceptors := connect.WithInterceptors(
+		UnaryClientInterceptor(NewJSONLogger(&clientLog), opts...),
+		StreamClientInterceptor(NewJSONLogger(&clientLog), opts...),
+	)
+	echo := connect.NewClient[structpb.Struct, structpb.Struct](srv.Client(), srv.URL+echoProcedure, clientInterceptors)
+	streamClient := connect.NewClient[structpb.Struct, structpb.Struct](srv.Client(), srv.URL+streamProcedure, clientInterceptors)
+
+	msg, err := structpb.NewStruct(map[string]any{"name": "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := echo.CallUnary(context.Background(), connect.NewRequest(msg)); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := streamClient.CallServerStream(context.Background(), connect.NewRequest(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Receive() {
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Payloads are embedded as JSON objects, not quoted.
+	const content = `{"name":"bob"}`
+	for _, tc := range []struct {
+		side   string
+		events map[string][]string
+		want   map[string]int
+	}{
+		// The server stream receives its request as a message of the stream.
+		{side: "server", events: payloadEvents(t, &serverLog), want: map[string]int{"request received": 2, "response sent": 3}},
+		{side: "client", events: payloadEvents(t, &clientLog), want: map[string]int{"request sent": 2, "response received": 3}},
+	} {
+		for msg, n := range tc.want {
+			got := tc.events[msg]
+			if len(got) != n {
+				t.Errorf("%s: %q logged %d times, want %d: %v", tc.side, msg, len(got), n, tc.events)
+				continue
+			}
+			for _, c := range got {
+				if c != content {
+					t.Errorf("%s: %q content = %s, want %s", tc.side, msg, c, content)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"path"
+	"sync"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+type procedureOptions struct {
+	pattern string
+	opts    []Option
+}
+
+// WithProcedureOptions applies opts, on top of the other options, to the procedures whose full method (e.g.
+// "/billing.v1.Service/Charge") matches pattern, in the syntax of path.Match (e.g. "/grpc.health.v1.Health/*").
+// Options of all matching patterns apply, in the order they were given. They are resolved once per procedure.
+//
+// For example, to log the payloads of a single method and silence health checks:
+//
+//	logging.UnaryServerInterceptor(logger,
+//		logging.WithProcedureOptions("/billing.v1.Service/Charge",
+//			logging.WithLogOnEvents(logging.StartCall, logging.FinishCall, logging.PayloadReceived)),
+//		logging.WithProcedureOptions("/grpc.health.v1.Health/*", logging.WithLogOnEvents()),
+//	)
+func WithProcedureOptions(pattern string, opts ...Option) Option {
+	return func(o *options) {
+		o.procedureOptions = append(o.procedureOptions[:len(o.procedureOptions):len(o.procedureOptions)],
+			procedureOptions{pattern: pattern, opts: opts})
+	}
+}
+
+// forProcedure returns the options of the procedure: o with the options of matching patterns applied, or o itself
+// if no pattern matches.
+func (o *options) forProcedure(fullMethod string) *options {
+	var po *options
+	for _, p := range o.procedureOptions {
+		if ok, _ := path.Match(p.pattern, fullMethod); !ok {
+			continue
+		}
+		if po == nil {
+			c := *o
+			po = &c
+		}
+		for _, opt := range p.opts {
+			opt(po)
+		}
+	}
+	if po == nil {
+		return o
+	}
+	return po
+}
+
+type procedureKey struct {
+	service, method string
+}
+
+// procedureOptionsCache holds the options of each procedure, resolved from the options of the interceptor.
+type procedureOptionsCache struct {
+	opts *options
+
+	mu          sync.RWMutex
+	byProcedure map[procedureKey]*options
+}
+
+func (c *procedureOptionsCache) get(meta interceptors.CallMeta) *options {
+	if len(c.opts.procedureOptions) == 0 {
+		return c.opts
+	}
+	key := procedureKey{service: meta.Service, method: meta.Method}
+	c.mu.RLock()
+	o, ok := c.byProcedure[key]
+	c.mu.RUnlock()
+	if ok {
+		return o
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if o, ok = c.byProcedure[key]; !ok {
+		if c.byProcedure == nil {
+			c.byProcedure = map[procedureKey]*options{}
+		}
+		o = c.opts.forProcedure(meta.FullMethod())
+		c.byProcedure[key] = o
+	}
+	return o
+}
@@ -0,0 +1,135 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+// callMeta returns the CallMeta of a server call of the given full method.
+func callMeta(fullMethod string, typ connect.StreamType) interceptors.CallMeta {
+	return interceptors.NewServerCallMeta(connect.Spec{Procedure: fullMethod, StreamType: typ}, nil)
+}
+
+func TestProcedureOptions(t *testing.T) {
+	notFound := connect.NewError(connect.CodeNotFound, errors.New("not found"))
+	opts := []Option{
+		WithLogOnEvents(StartCall, FinishCall),
+		WithProcedureOptions("/billing.v1.Service/Charge", WithLogOnEvents(StartCall, FinishCall, PayloadReceived)),
+		WithProcedureOptions("/grpc.health.v1.Health/*", WithLogOnEvents()),
+		// Both match Watch, the last one applies.
+		WithProcedureOptions("/billing.v1.Service/Watch*", WithLevels(func(connect.Code) Level { return LevelWarn })),
+		WithProcedureOptions("/billing.v1.Service/*", WithLevels(func(connect.Code) Level { return LevelError })),
+		WithProcedureOptions("/billing.v1.Service/Watch", WithLevels(func(connect.Code) Level { return LevelDebug })),
+	}
+	for _, tc := range []struct {
+		fullMethod string
+		typ        connect.StreamType
+		want       []logEntry
+	}{
+		{
+			fullMethod: "/billing.v1.Service/Charge",
+			want: []logEntry{
+				{level: LevelInfo, msg: "started call"},
+				{level: LevelInfo, msg: "request received"},
+				{level: LevelError, msg: "finished call"},
+			},
+		},
+		{
+			fullMethod: "/billing.v1.Service/Refund",
+			want: []logEntry{
+				{level: LevelInfo, msg: "started call"},
+				{level: LevelError, msg: "finished call"},
+			},
+		},
+		{fullMethod: "/grpc.health.v1.Health/Check"},
+		{
+			fullMethod: "/billing.v1.Service/Watch",
+			typ:        connect.StreamTypeBidi,
+			want: []logEntry{
+				{level: LevelInfo, msg: "started call"},
+				{level: LevelDebug, msg: "finished call"},
+			},
+		},
+		{
+			fullMethod: "/billing.v1.Service/WatchAll",
+			typ:        connect.StreamTypeServer,
+			want: []logEntry{
+				{level: LevelInfo, msg: "started call"},
+				{level: LevelError, msg: "finished call"},
+			},
+		},
+		{
+			// DefaultServerCodeToLevel.
+			fullMethod: "/other.v1.Service/Charge",
+			want: []logEntry{
+				{level: LevelInfo, msg: "started call"},
+				{level: LevelInfo, msg: "finished call"},
+			},
+		},
+	} {
+		t.Run(tc.fullMethod, func(t *testing.T) {
+			logger := &entriesLogger{minLevel: LevelDebug}
+			r, _ := reportable(logger, evaluateServerOpt(opts))(context.Background(), callMeta(tc.fullMethod, tc.typ))
+			r.PostMsgReceive(connect.NewRequest(wrapperspb.String("ping")), nil, time.Millisecond)
+			r.PostCall(notFound, time.Millisecond)
+
+			var got []logEntry
+			for _, e := range logger.entries {
+				got = append(got, logEntry{level: e.level, msg: e.msg})
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProcedureOptionsCache(t *testing.T) {
+	applied := 0
+	count := func(*options) { applied++ }
+	base := evaluateServerOpt([]Option{WithProcedureOptions("/billing.v1.Service/*", count)})
+	cache := &procedureOptionsCache{opts: base}
+
+	charge := cache.get(callMeta("/billing.v1.Service/Charge", connect.StreamTypeUnary))
+	if charge == base {
+		t.Fatal("want the options of the procedure, got the base options")
+	}
+	if got := cache.get(callMeta("/billing.v1.Service/Charge", connect.StreamTypeUnary)); got != charge || applied != 1 {
+		t.Fatalf("options resolved %d times, want once per procedure", applied)
+	}
+	if got := cache.get(callMeta("/billing.v1.Service/Refund", connect.StreamTypeUnary)); got == charge || applied != 2 {
+		t.Fatalf("options resolved %d times, want once per procedure", applied)
+	}
+	if got := cache.get(callMeta("/other.v1.Service/Charge", connect.StreamTypeUnary)); got != base || applied != 2 {
+		t.Fatal("want the base options for procedures matching no pattern")
+	}
+}
+
+func TestProcedureOptionsKeepBase(t *testing.T) {
+	base := evaluateServerOpt([]Option{
+		WithRedactedFields("password"),
+		WithProcedureOptions("/billing.v1.Service/*", WithRedactedFields("card"), WithLogOnEvents(PayloadReceived)),
+	})
+	po := base.forProcedure("/billing.v1.Service/Charge")
+
+	if want := []string{"password", "card"}; !reflect.DeepEqual(po.redactedFieldPaths, want) {
+		t.Fatalf("procedure redacted fields = %v, want %v", po.redactedFieldPaths, want)
+	}
+	if want := []string{"password"}; !reflect.DeepEqual(base.redactedFieldPaths, want) {
+		t.Fatalf("base redacted fields = %v, want %v", base.redactedFieldPaths, want)
+	}
+	if want := []LoggableEvent{StartCall, FinishCall}; !reflect.DeepEqual(base.loggableEvents, want) {
+		t.Fatalf("base events = %v, want %v", base.loggableEvents, want)
+	}
+}
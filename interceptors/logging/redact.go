@@ -0,0 +1,275 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// RedactedValue replaces the values of redacted string fields and headers in logs.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactedHeaders are the headers whose values are redacted in logs by default.
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// WithRedactedFields redacts the fields of logged payloads found at the given paths, made of proto field names
+// separated by dots (e.g. "credentials.password"). Paths go through repeated and map fields, whose keys aren't part
+// of paths: "users.password" redacts the password of every user. They also go through google.protobuf.Any fields,
+// as if the packed message was the field: "details.password" redacts the password of the message packed in
+// details. Fields marked with the debug_redact option are always redacted.
+//
+// String fields are replaced by RedactedValue, other fields are cleared. Any messages whose type can't be resolved
+// (with the Resolver of WithPayloadMarshalOptions, or the global registry) can't be inspected and are cleared.
+// Logged messages are copies, the messages of the call are never modified.
+func WithRedactedFields(paths ...string) Option {
+	return func(o *options) {
+		o.redactedFieldPaths = append(o.redactedFieldPaths[:len(o.redactedFieldPaths):len(o.redactedFieldPaths)], paths...)
+	}
+}
+
+// WithRedactedFieldNames redacts the fields of logged payloads whose proto name, at any depth, matches one of the
+// patterns, in the syntax of path.Match (e.g. "*password*" or "*_token"). The patterns are also matched against the
+// string keys of maps, including the keys of google.protobuf.Struct, to redact the values of matching entries. See
+// WithRedactedFields.
+func WithRedactedFieldNames(patterns ...string) Option {
+	return func(o *options) {
+		o.redactedFieldNames = append(o.redactedFieldNames[:len(o.redactedFieldNames):len(o.redactedFieldNames)], patterns...)
+	}
+}
+
+// WithRedactedHeaders adds headers, case-insensitive names or path.Match patterns (e.g. "x-*-token"), whose values
+// are replaced by RedactedValue in logs, to DefaultRedactedHeaders. See WithOnlyRedactedHeaders to replace the
+// defaults.
+func WithRedactedHeaders(names ...string) Option {
+	return func(o *options) {
+		o.redactedHeaders = append(o.redactedHeaders[:len(o.redactedHeaders):len(o.redactedHeaders)], names...)
+	}
+}
+
+// WithOnlyRedactedHeaders sets the headers, case-insensitive names or path.Match patterns, whose values are replaced by RedactedValue in logs,
+// replacing DefaultRedactedHeaders and the headers added by previous options.
+func WithOnlyRedactedHeaders(names ...string) Option {
+	return func(o *options) {
+		o.redactedHeaders = names
+	}
+}
+
+// redactedHeader reports whether the value of the header should be redacted.
+func (o *options) redactedHeader(name string) bool {
+	return matchHeader(o.redactedHeaders, strings.ToLower(name))
+}
+
+// redactedField reports whether the field, found at the given path, should be redacted.
+func (o *options) redactedField(fd protoreflect.FieldDescriptor, fieldPath string) bool {
+	if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDebugRedact() {
+		return true
+	}
+	for _, p := range o.redactedFieldPaths {
+		if p == fieldPath {
+			return true
+		}
+	}
+	return o.redactedName(string(fd.Name()))
+}
+
+// redactedName reports whether the field or map key name matches one of the patterns of WithRedactedFieldNames.
+func (o *options) redactedName(name string) bool {
+	for _, pattern := range o.redactedFieldNames {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns msg if it has no field to redact, or a copy of msg with its fields redacted.
+func (o *options) redact(msg proto.Message) proto.Message {
+	if !o.redactMessage(msg.ProtoReflect(), "", false) {
+		return msg
+	}
+	c := proto.Clone(msg)
+	o.redactMessage(c.ProtoReflect(), "", true)
+	return c
+}
+
+// redactMessage reports whether m has populated fields to redact, which it redacts if mask is true. Otherwise, it
+// stops at the first one.
+func (o *options) redactMessage(m protoreflect.Message, prefix string, mask bool) bool {
+	if m.Descriptor().FullName() == anyFullName {
+		return o.redactAny(m, prefix, mask)
+	}
+	found := false
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fieldPath := string(fd.Name())
+		if prefix != "" {
+			fieldPath = prefix + "." + fieldPath
+		}
+		if o.redactedField(fd, fieldPath) {
+			found = true
+			if mask {
+				redactField(m, fd)
+			}
+			return mask
+		}
+
+		if fd.IsMap() && fd.MapKey().Kind() == protoreflect.StringKind && len(o.redactedFieldNames) > 0 {
+			if o.redactMapEntries(m, fd, v, mask) {
+				found = true
+				if !mask {
+					return false
+				}
+				v = m.Get(fd)
+			}
+		}
+
+		elem := fd.Message()
+		if fd.IsMap() {
+			elem = fd.MapValue().Message()
+		}
+		if elem == nil {
+			return true
+		}
+		if mask {
+			v = m.Mutable(fd)
+		}
+		switch {
+		case fd.IsList():
+			l := v.List()
+			for i := 0; i < l.Len() && (mask || !found); i++ {
+				found = o.redactMessage(l.Get(i).Message(), fieldPath, mask) || found
+			}
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				found = o.redactMessage(mv.Message(), fieldPath, mask) || found
+				return mask || !found
+			})
+		default:
+			found = o.redactMessage(v.Message(), fieldPath, mask) || found
+		}
+		return mask || !found
+	})
+	return found
+}
+
+// redactMapEntries reports whether the map field fd, of value v, has entries whose key matches the patterns of
+// WithRedactedFieldNames, whose values it redacts if mask is true.
+func (o *options) redactMapEntries(m protoreflect.Message, fd protoreflect.FieldDescriptor, v protoreflect.Value, mask bool) bool {
+	var keys []protoreflect.MapKey
+	v.Map().Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		if o.redactedName(k.String()) {
+			keys = append(keys, k)
+			return mask
+		}
+		return true
+	})
+	if len(keys) == 0 || !mask {
+		return len(keys) > 0
+	}
+	mp := m.Mutable(fd).Map()
+	for _, k := range keys {
+		mp.Set(k, redactedMapValue(mp, fd.MapValue()))
+	}
+	return true
+}
+
+// redactedMapValue returns the value replacing a redacted map value: RedactedValue for strings and
+// google.protobuf.Value, an empty message or the default value otherwise.
+func redactedMapValue(mp protoreflect.Map, fd protoreflect.FieldDescriptor) protoreflect.Value {
+	switch {
+	case fd.Kind() == protoreflect.StringKind:
+		return protoreflect.ValueOfString(RedactedValue)
+	case fd.Message() != nil:
+		v := mp.NewValue()
+		if fd.Message().FullName() == valueFullName {
+			v.Message().Set(fd.Message().Fields().ByName("string_value"), protoreflect.ValueOfString(RedactedValue))
+		}
+		return v
+	default:
+		return fd.Default()
+	}
+}
+
+const (
+	anyFullName   protoreflect.FullName = "google.protobuf.Any"
+	valueFullName protoreflect.FullName = "google.protobuf.Value"
+)
+
+// resolver returns the resolver of the types packed in Any messages, the one rendering them.
+func (o *options) resolver() interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+} {
+	if o.payloadMarshalOptions.Resolver != nil {
+		return o.payloadMarshalOptions.Resolver
+	}
+	return protoregistry.GlobalTypes
+}
+
+// redactAny redacts the message packed in the google.protobuf.Any m, with the paths of its fields starting at prefix,
+// and packs it back if mask is true. An Any whose message can't be unpacked is cleared, as there is no telling what
+// it holds.
+func (o *options) redactAny(m protoreflect.Message, prefix string, mask bool) bool {
+	fields := m.Descriptor().Fields()
+	typeURL, value := fields.ByName("type_url"), fields.ByName("value")
+	if !m.Has(typeURL) && !m.Has(value) {
+		return false
+	}
+	resolver := o.resolver()
+	var packed protoreflect.Message
+	mt, err := resolver.FindMessageByURL(m.Get(typeURL).String())
+	if err == nil {
+		packed = mt.New()
+		err = proto.UnmarshalOptions{Resolver: resolver}.Unmarshal(m.Get(value).Bytes(), packed.Interface())
+	}
+	if err == nil {
+		if !o.redactMessage(packed, prefix, mask) {
+			return false
+		}
+		if !mask {
+			return true
+		}
+		var b []byte
+		if b, err = (proto.MarshalOptions{Deterministic: true}).Marshal(packed.Interface()); err == nil {
+			m.Set(value, protoreflect.ValueOfBytes(b))
+			return true
+		}
+	}
+	if mask {
+		m.Clear(typeURL)
+		m.Clear(value)
+	}
+	return true
+}
+
+// redactField replaces the strings of the field by RedactedValue, or clears the field if it doesn't hold strings.
+func redactField(m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	redacted := protoreflect.ValueOfString(RedactedValue)
+	switch {
+	case fd.IsList() && fd.Kind() == protoreflect.StringKind:
+		l := m.Mutable(fd).List()
+		for i := 0; i < l.Len(); i++ {
+			l.Set(i, redacted)
+		}
+	case fd.IsMap() && fd.MapValue().Kind() == protoreflect.StringKind:
+		mp := m.Mutable(fd).Map()
+		var keys []protoreflect.MapKey
+		mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+		for _, k := range keys {
+			mp.Set(k, redacted)
+		}
+	case !fd.IsList() && !fd.IsMap() && fd.Kind() == protoreflect.StringKind:
+		m.Set(fd, redacted)
+	default:
+		m.Clear(fd)
+	}
+}
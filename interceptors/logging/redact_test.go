@@ -0,0 +1,272 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// testTypes returns the descriptor of Envelope, in:
+//
+//	message Secret {
+//	  string password = 1 [debug_redact = true];
+//	  string user = 2;
+//	  int64 pin = 3;
+//	}
+//	message Envelope {
+//	  Secret secret = 1;
+//	  repeated Secret secrets = 2;
+//	  map<string, Secret> by_name = 3;
+//	  map<string, string> labels = 4;
+//	  google.protobuf.Any details = 5;
+//	  google.protobuf.Struct config = 6;
+//	  string api_token = 7;
+//	}
+//
+// and the types registry holding both messages.
+func testTypes(t *testing.T) (protoreflect.MessageDescriptor, *protoregistry.Types) {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		str      = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		msg      = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	password := field("password", 1, str, "", optional)
+	password.Options = &descriptorpb.FieldOptions{DebugRedact: proto.Bool(true)}
+	mapEntry := func(name, valueType string, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name:    proto.String(name),
+			Field:   []*descriptorpb.FieldDescriptorProto{field("key", 1, str, "", optional), field("value", 2, typ, valueType, optional)},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/v1/redact.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/any.proto", "google/protobuf/struct.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Secret"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					password,
+					field("user", 2, str, "", optional),
+					field("pin", 3, descriptorpb.FieldDescriptorProto_TYPE_INT64, "", optional),
+				},
+			},
+			{
+				Name: proto.String("Envelope"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("secret", 1, msg, ".test.v1.Secret", optional),
+					field("secrets", 2, msg, ".test.v1.Secret", repeated),
+					field("by_name", 3, msg, ".test.v1.Envelope.ByNameEntry", repeated),
+					field("labels", 4, msg, ".test.v1.Envelope.LabelsEntry", repeated),
+					field("details", 5, msg, ".google.protobuf.Any", optional),
+					field("config", 6, msg, ".google.protobuf.Struct", optional),
+					field("api_token", 7, str, "", optional),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					mapEntry("ByNameEntry", ".test.v1.Secret", msg),
+					mapEntry("LabelsEntry", "", str),
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := &protoregistry.Types{}
+	for i := 0; i < fd.Messages().Len(); i++ {
+		md := fd.Messages().Get(i)
+		if err := types.RegisterMessage(dynamicpb.NewMessageType(md)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fd.Messages().ByName("Envelope"), types
+}
+
+// newMessage returns a message of the given type, with the fields set from JSON.
+func newMessage(t *testing.T, md protoreflect.MessageDescriptor, types *protoregistry.Types, s string) proto.Message {
+	m := dynamicpb.NewMessage(md)
+	if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal([]byte(s), m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRedact(t *testing.T) {
+	envelope, types := testTypes(t)
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		in   string
+		want string
+	}{
+		{
+			name: "nothing to redact",
+			in:   `{"secret": {"user": "bob", "pin": "1234"}, "apiToken": "t"}`,
+			want: `{"secret": {"user": "bob", "pin": "1234"}, "apiToken": "t"}`,
+		},
+		{
+			name: "debug_redact",
+			in:   `{"secret": {"password": "p", "user": "bob"}, "secrets": [{"password": "p"}], "byName": {"a": {"password": "p"}}}`,
+			want: `{"secret": {"password": "[REDACTED]", "user": "bob"}, "secrets": [{"password": "[REDACTED]"}], "byName": {"a": {"password": "[REDACTED]"}}}`,
+		},
+		{
+			name: "paths through lists and maps",
+			opts: []Option{WithRedactedFields("secret.pin", "secrets.user", "by_name.pin", "labels")},
+			in:   `{"secret": {"user": "bob", "pin": "1"}, "secrets": [{"user": "a", "pin": "2"}, {"user": "b"}], "byName": {"a": {"user": "c", "pin": "3"}}, "labels": {"env": "prod"}}`,
+			want: `{"secret": {"user": "bob"}, "secrets": [{"user": "[REDACTED]", "pin": "2"}, {"user": "[REDACTED]"}], "byName": {"a": {"user": "c"}}, "labels": {"env": "[REDACTED]"}}`,
+		},
+		{
+			name: "name patterns",
+			opts: []Option{WithRedactedFieldNames("*_token", "pin")},
+			in:   `{"secret": {"user": "bob", "pin": "1"}, "secrets": [{"pin": "2"}], "apiToken": "t"}`,
+			want: `{"secret": {"user": "bob"}, "secrets": [{}], "apiToken": "[REDACTED]"}`,
+		},
+		{
+			name: "name patterns on map and struct keys",
+			opts: []Option{WithRedactedFieldNames("pass*")},
+			in:   `{"labels": {"env": "prod", "passphrase": "p"}, "config": {"host": "db", "password": "p", "nested": {"passkey": 1}, "list": [{"pass": true}]}}`,
+			want: `{"labels": {"env": "prod", "passphrase": "[REDACTED]"}, "config": {"host": "db", "password": "[REDACTED]", "nested": {"passkey": "[REDACTED]"}, "list": [{"pass": "[REDACTED]"}]}}`,
+		},
+		{
+			name: "any",
+			opts: []Option{WithRedactedFields("details.user")},
+			in:   `{"details": {"@type": "type.googleapis.com/test.v1.Secret", "password": "p", "user": "bob", "pin": "1"}}`,
+			want: `{"details": {"@type": "type.googleapis.com/test.v1.Secret", "password": "[REDACTED]", "user": "[REDACTED]", "pin": "1"}}`,
+		},
+		{
+			name: "any without fields to redact",
+			in:   `{"details": {"@type": "type.googleapis.com/test.v1.Secret", "user": "bob"}}`,
+			want: `{"details": {"@type": "type.googleapis.com/test.v1.Secret", "user": "bob"}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := evaluateServerOpt(append(tc.opts, WithPayloadMarshalOptions(protojson.MarshalOptions{Resolver: types})))
+			in := newMessage(t, envelope, types, tc.in)
+			orig := proto.Clone(in)
+
+			got := opts.redact(in)
+			if !proto.Equal(in, orig) {
+				t.Fatal("the message was modified")
+			}
+			assertMessageEqual(t, types, got, newMessage(t, envelope, types, tc.want))
+		})
+	}
+}
+
+func TestRedactUnresolvableAny(t *testing.T) {
+	envelope, types := testTypes(t)
+	details, err := anypb.New(structpb.NewStringValue("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := dynamicpb.NewMessage(envelope)
+	in.Set(envelope.Fields().ByName("api_token"), protoreflect.ValueOfString("t"))
+	in.Set(envelope.Fields().ByName("details"), protoreflect.ValueOfMessage(details.ProtoReflect()))
+	orig := proto.Clone(in)
+
+	// The types don't hold google.protobuf.Value.
+	got := evaluateServerOpt([]Option{WithPayloadMarshalOptions(protojson.MarshalOptions{Resolver: types})}).redact(in)
+	if !proto.Equal(in, orig) {
+		t.Fatal("the message was modified")
+	}
+	want := newMessage(t, envelope, types, `{"apiToken": "t", "details": {}}`)
+	if !proto.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// The global registry does.
+	if got := evaluateServerOpt(nil).redact(in); got != proto.Message(in) {
+		t.Fatalf("got %v, want the message itself", got)
+	}
+}
+
+// assertMessageEqual compares messages by their content, as the bytes of Any messages may differ.
+func assertMessageEqual(t *testing.T, types *protoregistry.Types, got, want proto.Message) {
+	t.Helper()
+	var g, w any
+	for _, m := range []struct {
+		msg proto.Message
+		v   *any
+	}{{got, &g}, {want, &w}} {
+		b, err := (protojson.MarshalOptions{Resolver: types}).Marshal(m.msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, m.v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Fatalf("got %v, want %v", g, w)
+	}
+}
+
+func TestRedactedHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		opts        []Option
+		redacted    []string
+		notRedacted []string
+	}{
+		{name: "defaults", redacted: []string{"authorization", "Cookie", "set-cookie"}, notRedacted: []string{"User-Agent", "X-Api-Key"}},
+		{name: "added", opts: []Option{WithRedactedHeaders("X-Api-Key")}, redacted: []string{"Authorization", "x-api-key"}, notRedacted: []string{"User-Agent"}},
+		{
+			name:        "replaced",
+			opts:        []Option{WithRedactedHeaders("X-Secret"), WithOnlyRedactedHeaders("X-Api-Key")},
+			redacted:    []string{"X-Api-Key"},
+			notRedacted: []string{"Authorization", "X-Secret"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := evaluateServerOpt(tc.opts)
+			for _, h := range tc.redacted {
+				if !o.redactedHeader(h) {
+					t.Errorf("%s is not redacted", h)
+				}
+			}
+			for _, h := range tc.notRedacted {
+				if o.redactedHeader(h) {
+					t.Errorf("%s is redacted", h)
+				}
+			}
+		})
+	}
+	if len(DefaultRedactedHeaders) != 4 {
+		t.Fatalf("options modified the defaults: %v", DefaultRedactedHeaders)
+	}
+
+	fields := evaluateServerOpt([]Option{WithRedactedHeaders("X-Api-Key")}).appendHeaderFields(nil, "request.header.", &HeaderFields{Allow: []string{"*"}}, http.Header{
+		"Authorization": {"Bearer t"},
+		"X-Api-Key":     {"k"},
+		"User-Agent":    {"test"},
+	})
+	want := Fields{"request.header.authorization", RedactedValue, "request.header.user-agent", "test", "request.header.x-api-key", RedactedValue}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("got %v, want %v", fields, want)
+	}
+}
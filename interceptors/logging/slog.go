@@ -9,6 +9,10 @@
 
 package logging
 
+import (
+	"log/slog"
+)
+
 // A Level is the importance or severity of a log event.
 // The higher the level, the more important or severe the event.
 type Level int
@@ -44,3 +48,9 @@ const (
 	LevelWarn  Level = 4
 	LevelError Level = 8
 )
+
+// SlogLevel returns the log/slog level of l. Levels share the numbering of log/slog, so the mapping is direct
+// and intermediate levels (e.g. LevelInfo+2 for a Notice level) are preserved.
+func (l Level) SlogLevel() slog.Level {
+	return slog.Level(l)
+}
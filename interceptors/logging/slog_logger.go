@@ -0,0 +1,65 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// SlogLogger returns a Logger logging to the given log/slog logger. Fields are added to the record as
+// attributes, and nothing is done if the handler isn't enabled for the level.
+func SlogLogger(l *slog.Logger) Logger {
+	return &slogLogger{logger: l}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// Enabled implements LevelEnabler.
+func (l *slogLogger) Enabled(ctx context.Context, level Level) bool {
+	return l.logger.Handler().Enabled(ctx, level.SlogLevel())
+}
+
+func (l *slogLogger) Log(ctx context.Context, level Level, msg string, fields ...any) {
+	h := l.logger.Handler()
+	if !h.Enabled(ctx, level.SlogLevel()) {
+		return
+	}
+
+	// Skip runtime.Callers and Log, so that the source of the record is the caller of Log.
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	r := slog.NewRecord(time.Now(), level.SlogLevel(), msg, pcs[0])
+	i := Fields(fields).Iterator()
+	for i.Next() {
+		k, v := i.At()
+		r.AddAttrs(slogAttr(k, v))
+	}
+	_ = h.Handle(ctx, r)
+}
+
+// slogAttr returns the attribute of a field. The common field types are matched first, so that their values are
+// stored as such in the attribute rather than through slog.AnyValue.
+func slogAttr(k string, v any) slog.Attr {
+	switch v := v.(type) {
+	case string:
+		return slog.String(k, v)
+	case int:
+		return slog.Int(k, v)
+	case int64:
+		return slog.Int64(k, v)
+	case bool:
+		return slog.Bool(k, v)
+	case time.Duration:
+		return slog.Duration(k, v)
+	case error:
+		return slog.String(k, v.Error())
+	default:
+		return slog.Any(k, v)
+	}
+}
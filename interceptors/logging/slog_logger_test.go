@@ -0,0 +1,83 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// recordHandler keeps the attributes of the last record it handled.
+type recordHandler struct {
+	slog.Handler
+	attrs []slog.Attr
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.attrs = h.attrs[:0]
+	r.Attrs(func(a slog.Attr) bool {
+		h.attrs = append(h.attrs, a)
+		return true
+	})
+	return nil
+}
+
+func TestSlogLoggerAttrs(t *testing.T) {
+	type custom struct{ A int }
+	h := &recordHandler{}
+	SlogLogger(slog.New(h)).Log(context.Background(), LevelInfo, "msg",
+		"string", "value",
+		"int", 1,
+		"int64", int64(2),
+		"bool", true,
+		"duration", time.Second,
+		"error", errors.New("failed"),
+		"custom", custom{A: 3},
+	)
+
+	want := []slog.Attr{
+		slog.String("string", "value"),
+		slog.Int("int", 1),
+		slog.Int64("int64", 2),
+		slog.Bool("bool", true),
+		slog.Duration("duration", time.Second),
+		slog.String("error", "failed"),
+		slog.Any("custom", custom{A: 3}),
+	}
+	if len(h.attrs) != len(want) {
+		t.Fatalf("got %v, want %v", h.attrs, want)
+	}
+	for i := range want {
+		if !h.attrs[i].Equal(want[i]) || h.attrs[i].Value.Kind() != want[i].Value.Kind() {
+			t.Errorf("attribute %d: got %v (%v), want %v (%v)", i, h.attrs[i], h.attrs[i].Value.Kind(), want[i], want[i].Value.Kind())
+		}
+	}
+}
+
+func BenchmarkSlogLogger(b *testing.B) {
+	logger := SlogLogger(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	fields := Fields{
+		"protocol", "connect",
+		"grpc.service", "ping.v1.PingService",
+		"grpc.method", "Ping",
+		"grpc.code", "ok",
+		"grpc.time_ms", 12,
+		"grpc.duration", 12 * time.Millisecond,
+		"retry.attempt", int64(1),
+		"grpc.is_client", false,
+		"error", errors.New("failed"),
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Log(ctx, LevelInfo, "finished call", fields...)
+	}
+}
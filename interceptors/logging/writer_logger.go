@@ -0,0 +1,215 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type loggerOptions struct {
+	minLevel   Level
+	timeFormat string
+}
+
+// LoggerOption configures the loggers returned by NewJSONLogger and NewLogfmtLogger.
+type LoggerOption func(*loggerOptions)
+
+func evaluateLoggerOptions(opts []LoggerOption) *loggerOptions {
+	optCopy := &loggerOptions{minLevel: LevelInfo, timeFormat: time.RFC3339Nano}
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithMinLevel sets the minimum level of the events to write. Defaults to LevelInfo.
+func WithMinLevel(level Level) LoggerOption {
+	return func(o *loggerOptions) {
+		o.minLevel = level
+	}
+}
+
+// WithLoggerTimeFormat sets the layout of the time of events, as per time.Format. An empty layout omits the
+// time. Defaults to time.RFC3339Nano.
+func WithLoggerTimeFormat(layout string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.timeFormat = layout
+	}
+}
+
+// writerLogger writes events to a writer, one line per event, encoded by the appendEvent function. It is safe
+// for concurrent use: lines are encoded in a pooled buffer and written with a single Write call.
+type writerLogger struct {
+	opts        *loggerOptions
+	appendEvent func(buf []byte, t string, level Level, msg string, fields Fields) []byte
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Enabled implements LevelEnabler.
+func (l *writerLogger) Enabled(_ context.Context, level Level) bool {
+	return level >= l.opts.minLevel
+}
+
+var bufPool = sync.Pool{New: func() any { b := make([]byte, 0, 512); return &b }}
+
+func (l *writerLogger) log(level Level, msg string, fields []any) {
+	if level < l.opts.minLevel {
+		return
+	}
+	var t string
+	if l.opts.timeFormat != "" {
+		t = time.Now().Format(l.opts.timeFormat)
+	}
+
+	bp := bufPool.Get().(*[]byte)
+	buf := l.appendEvent((*bp)[:0], t, level, msg, fields)
+	buf = append(buf, '\n')
+
+	l.mu.Lock()
+	// Write errors are ignored, like logging packages do: there is nowhere to report them.
+	_, _ = l.w.Write(buf)
+	l.mu.Unlock()
+
+	*bp = buf
+	bufPool.Put(bp)
+}
+
+// textValue returns the text rendering of values without a natural JSON or logfmt literal, and whether there is
+// one: errors by their message, durations like "1.5s", times in RFC 3339, Stringers by their String method and raw
+// JSON as is.
+func textValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.RawMessage:
+		return string(v), true
+	case error:
+		return v.Error(), true
+	case time.Duration:
+		return v.String(), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case fmt.Stringer:
+		return v.String(), true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+// appendNumber appends the literal of booleans and numbers, and reports whether v was one. Non finite floats
+// aren't valid JSON numbers and are left to the caller.
+func appendNumber(buf []byte, v any) ([]byte, bool) {
+	switch v := v.(type) {
+	case bool:
+		return strconv.AppendBool(buf, v), true
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int64:
+		return strconv.AppendInt(buf, v, 10), true
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint64:
+		return strconv.AppendUint(buf, v, 10), true
+	case float32:
+		if math.IsInf(float64(v), 0) || math.IsNaN(float64(v)) {
+			return buf, false
+		}
+		return strconv.AppendFloat(buf, float64(v), 'g', -1, 32), true
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return buf, false
+		}
+		return strconv.AppendFloat(buf, v, 'g', -1, 64), true
+	}
+	return buf, false
+}
+
+// appendQuoted appends s as a JSON string, which is also how logfmt quotes values. Invalid UTF-8 is replaced by
+// the replacement character.
+func appendQuoted(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < 0x20 || c == 0x7f:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, "\ufffd"...)
+		case r == '\u2028' || r == '\u2029':
+			// Valid JSON, but line terminators in JavaScript.
+			buf = append(buf, `\u202`...)
+			buf = append(buf, hex[r&0xf])
+		default:
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+// appendJSONValue appends v as a JSON value, falling back to encoding/json, then to its fmt rendering. Valid raw
+// JSON is embedded as is, compacted to keep the event on one line, invalid raw JSON is quoted.
+func appendJSONValue(buf []byte, v any) []byte {
+	if v == nil {
+		return append(buf, "null"...)
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		b := bytes.NewBuffer(buf)
+		if err := json.Compact(b, raw); err == nil {
+			return b.Bytes()
+		}
+	}
+	if b, ok := appendNumber(buf, v); ok {
+		return b
+	}
+	if s, ok := textValue(v); ok {
+		return appendQuoted(buf, s)
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return append(buf, b...)
+	}
+	return appendQuoted(buf, fmt.Sprintf("%+v", v))
+}
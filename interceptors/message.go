@@ -0,0 +1,32 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package interceptors
+
+import (
+	"connectrpc.com/connect"
+)
+
+// messageRequest reports a message which connect doesn't wrap in a connect.Request: the messages received on
+// streams, and the response received by unary clients. Only its Any method is meaningful, its headers are empty.
+type messageRequest struct {
+	connect.Request[struct{}]
+
+	msg any
+}
+
+func (m *messageRequest) Any() any {
+	return m.msg
+}
+
+// messageResponse reports a message which connect doesn't wrap in a connect.Response: the messages sent on
+// streams, and the request sent by unary clients. Only its Any method is meaningful, its headers are empty.
+type messageResponse struct {
+	connect.Response[struct{}]
+
+	msg any
+}
+
+func (m *messageResponse) Any() any {
+	return m.msg
+}
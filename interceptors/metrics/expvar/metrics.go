@@ -0,0 +1,226 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package expvar
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+var (
+	_ interceptors.ServerReportable = &Metrics{}
+	_ interceptors.ClientReportable = &Metrics{}
+)
+
+// Metrics keeps per-procedure metrics in memory, without any dependency: calls started and handled (by code),
+// messages sent and received and latency quantiles computed with a streaming quantile sketch. Metrics are
+// exposed as JSON, through the standard library's expvar (see Publish) or as an http.Handler.
+//
+// Latency quantiles are computed over the lifetime of the process.
+type Metrics struct {
+	opts *options
+
+	mu     sync.RWMutex
+	server map[string]*procedureMetrics
+	client map[string]*procedureMetrics
+}
+
+// NewMetrics returns new, empty, Metrics.
+func NewMetrics(opts ...Option) *Metrics {
+	return &Metrics{
+		opts:   evaluateOptions(opts),
+		server: map[string]*procedureMetrics{},
+		client: map[string]*procedureMetrics{},
+	}
+}
+
+// Snapshot holds the metrics of every procedure seen, by full method (e.g. "/ping.v1.PingService/Ping").
+type Snapshot struct {
+	Server map[string]ProcedureSnapshot `json:"server"`
+	Client map[string]ProcedureSnapshot `json:"client"`
+}
+
+// ProcedureSnapshot holds the metrics of a procedure.
+type ProcedureSnapshot struct {
+	Type        interceptors.ConnectType `json:"type"`
+	Started     uint64                   `json:"started"`
+	Handled     map[string]uint64        `json:"handled"`
+	MsgSent     uint64                   `json:"msg_sent"`
+	MsgReceived uint64                   `json:"msg_received"`
+	// LatencySeconds maps quantiles (e.g. "0.99") to the handling time of calls, in seconds.
+	LatencySeconds map[string]float64 `json:"latency_seconds"`
+}
+
+// Snapshot returns a copy of the current metrics.
+func (m *Metrics) Snapshot() Snapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return Snapshot{Server: snapshot(m.server), Client: snapshot(m.client)}
+}
+
+func snapshot(procedures map[string]*procedureMetrics) map[string]ProcedureSnapshot {
+	s := make(map[string]ProcedureSnapshot, len(procedures))
+	for name, p := range procedures {
+		s[name] = p.snapshot()
+	}
+	return s
+}
+
+// ServeHTTP serves the current metrics as JSON.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(m.Snapshot())
+}
+
+// String returns the current metrics as JSON, which makes Metrics an expvar.Var.
+func (m *Metrics) String() string {
+	b, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+var _ expvar.Var = &Metrics{}
+
+// Publish publishes the metrics under the given expvar name, served by the expvar handler on /debug/vars.
+// Like expvar.Publish, it panics if the name is already registered.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, m)
+}
+
+// ServerReporter implements interceptors.ServerReportable.
+func (m *Metrics) ServerReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return m.reporter(m.server, meta, false), ctx
+}
+
+// ClientReporter implements interceptors.ClientReportable.
+func (m *Metrics) ClientReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return m.reporter(m.client, meta, true), ctx
+}
+
+func (m *Metrics) reporter(procedures map[string]*procedureMetrics, meta interceptors.CallMeta, client bool) *reporter {
+	name := meta.FullMethod()
+	m.mu.RLock()
+	p, ok := procedures[name]
+	m.mu.RUnlock()
+	if !ok {
+		m.mu.Lock()
+		if p, ok = procedures[name]; !ok {
+			p = newProcedureMetrics(interceptors.ConnectTypeOf(meta.Typ), m.opts.targets)
+			procedures[name] = p
+		}
+		m.mu.Unlock()
+	}
+	p.started()
+	return &reporter{procedure: p, client: client}
+}
+
+// UnaryServerInterceptor is a connect server-side interceptor that provides in-memory metrics for Unary RPCs.
+func (m *Metrics) UnaryServerInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryServerInterceptor(m)
+}
+
+// StreamServerInterceptor is a connect server-side interceptor that provides in-memory metrics for Streaming RPCs.
+func (m *Metrics) StreamServerInterceptor() connect.Interceptor {
+	return interceptors.StreamServerInterceptor(m)
+}
+
+// UnaryClientInterceptor is a connect client-side interceptor that provides in-memory metrics for Unary RPCs.
+func (m *Metrics) UnaryClientInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryClientInterceptor(m)
+}
+
+// StreamClientInterceptor is a connect client-side interceptor that provides in-memory metrics for Streaming RPCs.
+func (m *Metrics) StreamClientInterceptor() connect.Interceptor {
+	return interceptors.StreamClientInterceptor(m)
+}
+
+type procedureMetrics struct {
+	typ interceptors.ConnectType
+
+	mu          sync.Mutex
+	startedN    uint64
+	handled     map[string]uint64
+	msgSent     uint64
+	msgReceived uint64
+	latency     *quantileStream
+}
+
+func newProcedureMetrics(typ interceptors.ConnectType, targets []target) *procedureMetrics {
+	return &procedureMetrics{typ: typ, handled: map[string]uint64{}, latency: newQuantileStream(targets)}
+}
+
+func (p *procedureMetrics) started() {
+	p.mu.Lock()
+	p.startedN++
+	p.mu.Unlock()
+}
+
+func (p *procedureMetrics) snapshot() ProcedureSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := ProcedureSnapshot{
+		Type:           p.typ,
+		Started:        p.startedN,
+		Handled:        make(map[string]uint64, len(p.handled)),
+		MsgSent:        p.msgSent,
+		MsgReceived:    p.msgReceived,
+		LatencySeconds: make(map[string]float64, len(p.latency.targets)),
+	}
+	for code, n := range p.handled {
+		s.Handled[code] = n
+	}
+	for _, t := range p.latency.targets {
+		if v := p.latency.query(t.quantile); !math.IsNaN(v) {
+			s.LatencySeconds[strconv.FormatFloat(t.quantile, 'f', -1, 64)] = v
+		}
+	}
+	return s
+}
+
+type reporter struct {
+	procedure *procedureMetrics
+	client    bool
+}
+
+func (r *reporter) PostCall(err error, rpcDuration time.Duration) {
+	code := interceptors.CodeString(err)
+	r.procedure.mu.Lock()
+	defer r.procedure.mu.Unlock()
+
+	r.procedure.handled[code]++
+	r.procedure.latency.insert(rpcDuration.Seconds())
+}
+
+func (r *reporter) PostMsgSend(_ connect.AnyResponse, err error, _ time.Duration) {
+	// The error of unary client calls is the one of the whole call, whose request was sent anyway.
+	if err != nil && !(r.client && r.procedure.typ == interceptors.Unary) {
+		return
+	}
+	r.procedure.mu.Lock()
+	r.procedure.msgSent++
+	r.procedure.mu.Unlock()
+}
+
+func (r *reporter) PostMsgReceive(_ connect.AnyRequest, err error, _ time.Duration) {
+	if err != nil {
+		return
+	}
+	r.procedure.mu.Lock()
+	r.procedure.msgReceived++
+	r.procedure.mu.Unlock()
+}
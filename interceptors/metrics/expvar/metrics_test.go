@@ -0,0 +1,153 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package expvar_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+	connectexpvar "github.com/svrana/go-connect-middleware/interceptors/metrics/expvar"
+)
+
+const (
+	echoProcedure   = "/test.v1.TestService/Echo"
+	failProcedure   = "/test.v1.TestService/Fail"
+	streamProcedure = "/test.v1.TestService/Stream"
+)
+
+// callAll calls, once each, the Echo and Fail unary procedures and the Stream server stream procedure of a server
+// reporting to m, with a client reporting to m.
+func callAll(t *testing.T, m *connectexpvar.Metrics) {
+	serverInterceptors := connect.WithInterceptors(m.UnaryServerInterceptor(), m.StreamServerInterceptor())
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		return connect.NewResponse(req.Msg), nil
+	}, serverInterceptors))
+	mux.Handle(failProcedure, connect.NewUnaryHandler(failProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("not found"))
+	}, serverInterceptors))
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+		for i := 0; i < 2; i++ {
+			if err := stream.Send(req.Msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, serverInterceptors))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	clientInterceptors := connect.WithInterceptors(m.UnaryClientInterceptor(), m.StreamClientInterceptor())
+	echo := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+echoProcedure, clientInterceptors)
+	if _, err := echo.CallUnary(ctx, connect.NewRequest(wrapperspb.String("hi"))); err != nil {
+		t.Fatal(err)
+	}
+	fail := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+failProcedure, clientInterceptors)
+	if _, err := fail.CallUnary(ctx, connect.NewRequest(wrapperspb.String("hi"))); connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("got %v, want not found", err)
+	}
+	streamClient := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+streamProcedure, clientInterceptors)
+	stream, err := streamClient.CallServerStream(ctx, connect.NewRequest(wrapperspb.String("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Receive() {
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkSnapshot checks the counters of every procedure of got, and that latencies are known for every quantile.
+func checkSnapshot(t *testing.T, got connectexpvar.Snapshot) {
+	t.Helper()
+	ok := map[string]uint64{interceptors.CodeOK: 1}
+	want := connectexpvar.Snapshot{
+		Server: map[string]connectexpvar.ProcedureSnapshot{
+			echoProcedure:   {Type: interceptors.Unary, Started: 1, Handled: ok, MsgSent: 1, MsgReceived: 1},
+			failProcedure:   {Type: interceptors.Unary, Started: 1, Handled: map[string]uint64{"not_found": 1}, MsgReceived: 1},
+			streamProcedure: {Type: interceptors.ServerStream, Started: 1, Handled: ok, MsgSent: 2, MsgReceived: 1},
+		},
+		Client: map[string]connectexpvar.ProcedureSnapshot{
+			echoProcedure: {Type: interceptors.Unary, Started: 1, Handled: ok, MsgSent: 1, MsgReceived: 1},
+			// The request of failed calls was sent all the same.
+			failProcedure:   {Type: interceptors.Unary, Started: 1, Handled: map[string]uint64{"not_found": 1}, MsgSent: 1},
+			streamProcedure: {Type: interceptors.ServerStream, Started: 1, Handled: ok, MsgSent: 1, MsgReceived: 2},
+		},
+	}
+	for _, procedures := range []map[string]connectexpvar.ProcedureSnapshot{got.Server, got.Client} {
+		for name, p := range procedures {
+			if len(p.LatencySeconds) != len(connectexpvar.DefaultQuantiles) {
+				t.Errorf("%s: latencies %v, want one per default quantile", name, p.LatencySeconds)
+			}
+			p.LatencySeconds = nil
+			procedures[name] = p
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := connectexpvar.NewMetrics()
+	callAll(t, m)
+	checkSnapshot(t, m.Snapshot())
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	m := connectexpvar.NewMetrics()
+	callAll(t, m)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Fatalf("content type %q, want JSON", got)
+	}
+	var got connectexpvar.Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	checkSnapshot(t, got)
+}
+
+func TestMetrics_Publish(t *testing.T) {
+	m := connectexpvar.NewMetrics()
+	callAll(t, m)
+	// Names can only be published once per process, including across test runs.
+	name := fmt.Sprintf("connect_test_%p", m)
+	m.Publish(name)
+
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatal("metrics not published")
+	}
+	if v.String() != m.String() {
+		t.Fatalf("published %s, want %s", v.String(), m.String())
+	}
+	var got connectexpvar.Snapshot
+	if err := json.Unmarshal([]byte(m.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	checkSnapshot(t, got)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("publishing twice under the same name did not panic")
+		}
+	}()
+	connectexpvar.NewMetrics().Publish(name)
+}
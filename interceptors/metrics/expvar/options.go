@@ -0,0 +1,33 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package expvar
+
+// DefaultQuantiles are the latency quantiles tracked by default, mapped to their allowed error.
+var DefaultQuantiles = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+
+type options struct {
+	targets []target
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	WithQuantiles(DefaultQuantiles)(optCopy)
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithQuantiles sets the latency quantiles to track, mapped to their allowed error (e.g. 0.99 within 0.001).
+// Defaults to DefaultQuantiles.
+func WithQuantiles(quantiles map[float64]float64) Option {
+	return func(o *options) {
+		o.targets = make([]target, 0, len(quantiles))
+		for q, eps := range quantiles {
+			o.targets = append(o.targets, target{quantile: q, epsilon: eps})
+		}
+	}
+}
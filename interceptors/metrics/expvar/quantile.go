@@ -0,0 +1,138 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package expvar
+
+import (
+	"math"
+	"sort"
+)
+
+// quantileStream is a streaming quantile sketch implementing the targeted quantiles algorithm of Cormode, Korn,
+// Muthukrishnan and Srivastava ("Effective Computation of Biased Quantiles over Data Streams"). It keeps a small
+// summary of the observed values, from which each target quantile can be queried within its error bound.
+//
+// quantileStream is not safe for concurrent use.
+type quantileStream struct {
+	targets []target
+	buf     []float64
+	summary []summarySample
+	n       float64
+}
+
+// target is a quantile to be queried, with its allowed error (e.g. 0.99 within 0.001).
+type target struct {
+	quantile float64
+	epsilon  float64
+}
+
+type summarySample struct {
+	value float64
+	// width is the difference between the lowest possible rank of this sample and the one of the previous sample.
+	width float64
+	// delta is the difference between the highest and the lowest possible rank of this sample.
+	delta float64
+}
+
+const quantileBufferSize = 500
+
+func newQuantileStream(targets []target) *quantileStream {
+	return &quantileStream{targets: targets, buf: make([]float64, 0, quantileBufferSize)}
+}
+
+func (s *quantileStream) insert(v float64) {
+	s.buf = append(s.buf, v)
+	if len(s.buf) == cap(s.buf) {
+		s.flush()
+	}
+}
+
+// query returns the value at quantile q, or NaN if nothing was observed.
+func (s *quantileStream) query(q float64) float64 {
+	s.flush()
+	if len(s.summary) == 0 {
+		return math.NaN()
+	}
+	t := math.Ceil(q * s.n)
+	t += math.Ceil(s.invariant(t) / 2)
+	prev := s.summary[0]
+	var r float64
+	for _, c := range s.summary[1:] {
+		r += prev.width
+		if r+c.width+c.delta > t {
+			return prev.value
+		}
+		prev = c
+	}
+	return prev.value
+}
+
+// invariant is the maximum error allowed at rank r, the minimum of the error allowed by every target.
+func (s *quantileStream) invariant(r float64) float64 {
+	m := math.MaxFloat64
+	for _, t := range s.targets {
+		var f float64
+		if t.quantile*s.n <= r {
+			f = (2 * t.epsilon * r) / t.quantile
+		} else {
+			f = (2 * t.epsilon * (s.n - r)) / (1 - t.quantile)
+		}
+		if f < m {
+			m = f
+		}
+	}
+	return m
+}
+
+// flush merges the buffered values into the summary, and compresses it.
+func (s *quantileStream) flush() {
+	if len(s.buf) == 0 {
+		return
+	}
+	sort.Float64s(s.buf)
+
+	var r float64
+	i := 0
+	for _, v := range s.buf {
+		for i < len(s.summary) && s.summary[i].value <= v {
+			r += s.summary[i].width
+			i++
+		}
+		delta := 0.0
+		if i > 0 && i < len(s.summary) {
+			delta = math.Max(0, math.Floor(s.invariant(r))-1)
+		}
+		s.summary = append(s.summary, summarySample{})
+		copy(s.summary[i+1:], s.summary[i:])
+		s.summary[i] = summarySample{value: v, width: 1, delta: delta}
+		s.n++
+		r++
+		i++
+	}
+	s.buf = s.buf[:0]
+	s.compress()
+}
+
+// compress merges adjacent samples whenever the merged sample still satisfies the error invariant.
+func (s *quantileStream) compress() {
+	if len(s.summary) < 2 {
+		return
+	}
+	x := s.summary[len(s.summary)-1]
+	xi := len(s.summary) - 1
+	r := s.n - 1 - x.width
+	for i := len(s.summary) - 2; i >= 0; i-- {
+		c := s.summary[i]
+		if c.width+x.width+x.delta <= s.invariant(r) {
+			x.width += c.width
+			s.summary[xi] = x
+			copy(s.summary[i:], s.summary[i+1:])
+			s.summary = s.summary[:len(s.summary)-1]
+			xi--
+		} else {
+			x = c
+			xi = i
+		}
+		r -= c.width
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package expvar
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQuantileStreamEmpty(t *testing.T) {
+	s := newQuantileStream([]target{{quantile: 0.5, epsilon: 0.05}})
+	if got := s.query(0.5); !math.IsNaN(got) {
+		t.Fatalf("got %v, want NaN", got)
+	}
+}
+
+func TestQuantileStreamAccuracy(t *testing.T) {
+	const n = 100000
+	targets := []target{{quantile: 0.5, epsilon: 0.05}, {quantile: 0.9, epsilon: 0.01}, {quantile: 0.99, epsilon: 0.001}}
+	s := newQuantileStream(targets)
+	// Values are the ranks 1..n in random order, so the value found at a quantile is its rank.
+	for _, v := range rand.New(rand.NewSource(1)).Perm(n) {
+		s.insert(float64(v + 1))
+	}
+
+	for _, tc := range targets {
+		got := s.query(tc.quantile)
+		if want := tc.quantile * n; math.Abs(got-want) > tc.epsilon*n {
+			t.Errorf("quantile %v: got rank %v, want %v +/- %v", tc.quantile, got, want, tc.epsilon*n)
+		}
+	}
+	if len(s.summary) > n/10 {
+		t.Errorf("summary holds %d samples for %d values, want it compressed", len(s.summary), n)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package otel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+// Attribute keys from the OpenTelemetry semantic conventions for RPC.
+const (
+	RPCSystemKey              = attribute.Key("rpc.system")
+	RPCServiceKey             = attribute.Key("rpc.service")
+	RPCMethodKey              = attribute.Key("rpc.method")
+	RPCConnectRPCErrorCodeKey = attribute.Key("rpc.connect_rpc.error_code")
+	rpcSystemConnectRPC       = "connect_rpc"
+)
+
+var (
+	_ interceptors.ServerReportable = &Metrics{}
+	_ interceptors.ClientReportable = &Metrics{}
+)
+
+// Metrics records the RPC metrics of the OpenTelemetry semantic conventions:
+// rpc.{server,client}.duration, rpc.{server,client}.request.size, rpc.{server,client}.response.size,
+// rpc.{server,client}.requests_per_rpc and rpc.{server,client}.responses_per_rpc.
+//
+// The request and response sizes are the encoded sizes of protobuf messages, recorded for every message sent or
+// received on either side, streams included.
+type Metrics struct {
+	opts   *options
+	server instruments
+	client instruments
+}
+
+type instruments struct {
+	duration        metric.Float64Histogram
+	requestSize     metric.Int64Histogram
+	responseSize    metric.Int64Histogram
+	requestsPerRPC  metric.Int64Histogram
+	responsesPerRPC metric.Int64Histogram
+}
+
+// NewMetrics returns new Metrics recording to a meter of the given provider.
+func NewMetrics(mp metric.MeterProvider, opts ...Option) (*Metrics, error) {
+	meter := mp.Meter(ScopeName)
+	m := &Metrics{opts: evaluateOptions(opts)}
+
+	var err error
+	if m.server, err = newInstruments(meter, "rpc.server"); err != nil {
+		return nil, err
+	}
+	if m.client, err = newInstruments(meter, "rpc.client"); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func newInstruments(meter metric.Meter, prefix string) (instruments, error) {
+	var (
+		i    instruments
+		err  error
+		errs []error
+	)
+	i.duration, err = meter.Float64Histogram(prefix+".duration",
+		metric.WithDescription("Measures the duration of inbound RPC."), metric.WithUnit("ms"))
+	errs = append(errs, err)
+	i.requestSize, err = meter.Int64Histogram(prefix+".request.size",
+		metric.WithDescription("Measures size of RPC request messages (uncompressed)."), metric.WithUnit("By"))
+	errs = append(errs, err)
+	i.responseSize, err = meter.Int64Histogram(prefix+".response.size",
+		metric.WithDescription("Measures size of RPC response messages (uncompressed)."), metric.WithUnit("By"))
+	errs = append(errs, err)
+	i.requestsPerRPC, err = meter.Int64Histogram(prefix+".requests_per_rpc",
+		metric.WithDescription("Measures the number of messages received per RPC. Should be 1 for all non-streaming RPCs."), metric.WithUnit("{count}"))
+	errs = append(errs, err)
+	i.responsesPerRPC, err = meter.Int64Histogram(prefix+".responses_per_rpc",
+		metric.WithDescription("Measures the number of messages sent per RPC. Should be 1 for all non-streaming RPCs."), metric.WithUnit("{count}"))
+	errs = append(errs, err)
+	return i, errors.Join(errs...)
+}
+
+// ServerReporter implements interceptors.ServerReportable.
+func (m *Metrics) ServerReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return m.newReporter(ctx, meta, &m.server), ctx
+}
+
+// ClientReporter implements interceptors.ClientReportable.
+func (m *Metrics) ClientReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return m.newReporter(ctx, meta, &m.client), ctx
+}
+
+func (m *Metrics) newReporter(ctx context.Context, meta interceptors.CallMeta, i *instruments) *reporter {
+	attrs := make([]attribute.KeyValue, 0, len(m.opts.attributes)+4)
+	attrs = append(attrs, m.opts.attributes...)
+	attrs = append(attrs,
+		RPCSystemKey.String(rpcSystemConnectRPC),
+		RPCServiceKey.String(meta.Service),
+		RPCMethodKey.String(meta.Method),
+	)
+	return &reporter{
+		// Measurements recorded with a done context are dropped, and canceled calls are finished with one.
+		ctx:         context.WithoutCancel(ctx),
+		callMeta:    meta,
+		instruments: i,
+		attrs:       attrs,
+		set:         metric.WithAttributes(attrs...),
+	}
+}
+
+// UnaryServerInterceptor is a connect server-side interceptor that provides OpenTelemetry metrics for Unary RPCs.
+func (m *Metrics) UnaryServerInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryServerInterceptor(m)
+}
+
+// StreamServerInterceptor is a connect server-side interceptor that provides OpenTelemetry metrics for Streaming RPCs.
+func (m *Metrics) StreamServerInterceptor() connect.Interceptor {
+	return interceptors.StreamServerInterceptor(m)
+}
+
+// UnaryClientInterceptor is a connect client-side interceptor that provides OpenTelemetry metrics for Unary RPCs.
+func (m *Metrics) UnaryClientInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryClientInterceptor(m)
+}
+
+// StreamClientInterceptor is a connect client-side interceptor that provides OpenTelemetry metrics for Streaming RPCs.
+func (m *Metrics) StreamClientInterceptor() connect.Interceptor {
+	return interceptors.StreamClientInterceptor(m)
+}
+
+type reporter struct {
+	ctx         context.Context
+	callMeta    interceptors.CallMeta
+	instruments *instruments
+	attrs       []attribute.KeyValue
+	set         metric.MeasurementOption
+
+	// requests and responses count messages from the point of view of the server: requests are received by
+	// servers and sent by clients. Streams may send and receive concurrently, and be finished from yet another
+	// goroutine.
+	requests, responses atomic.Int64
+}
+
+func (r *reporter) PostCall(err error, rpcDuration time.Duration) {
+	set := r.set
+	if err != nil && !errors.Is(err, io.EOF) {
+		attrs := make([]attribute.KeyValue, len(r.attrs), len(r.attrs)+1)
+		copy(attrs, r.attrs)
+		set = metric.WithAttributes(append(attrs, RPCConnectRPCErrorCodeKey.String(connect.CodeOf(err).String()))...)
+	}
+	r.instruments.duration.Record(r.ctx, float64(rpcDuration)/float64(time.Millisecond), set)
+	r.instruments.requestsPerRPC.Record(r.ctx, r.requests.Load(), set)
+	r.instruments.responsesPerRPC.Record(r.ctx, r.responses.Load(), set)
+}
+
+func (r *reporter) PostMsgSend(res connect.AnyResponse, err error, _ time.Duration) {
+	// The error of unary client calls is the one of the whole call, whose request was sent anyway.
+	if err != nil && !(r.callMeta.IsClient && r.callMeta.Typ == connect.StreamTypeUnary) {
+		return
+	}
+	size := r.instruments.responseSize
+	if r.callMeta.IsClient {
+		r.requests.Add(1)
+		size = r.instruments.requestSize
+	} else {
+		r.responses.Add(1)
+	}
+	if res != nil {
+		r.recordSize(size, res.Any())
+	}
+}
+
+func (r *reporter) PostMsgReceive(req connect.AnyRequest, err error, _ time.Duration) {
+	if err != nil {
+		return
+	}
+	size := r.instruments.requestSize
+	if r.callMeta.IsClient {
+		r.responses.Add(1)
+		size = r.instruments.responseSize
+	} else {
+		r.requests.Add(1)
+	}
+	if req != nil {
+		r.recordSize(size, req.Any())
+	}
+}
+
+func (r *reporter) recordSize(h metric.Int64Histogram, msg any) {
+	if m, ok := msg.(proto.Message); ok {
+		h.Record(r.ctx, int64(proto.Size(m)), r.set)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/metrics/otel"
+)
+
+const (
+	echoProcedure = "/test.v1.TestService/Echo"
+	failProcedure = "/test.v1.TestService/Fail"
+	chatProcedure = "/test.v1.TestService/Chat"
+)
+
+type clients struct {
+	echo, fail, chat *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue]
+}
+
+func setup(t *testing.T) (*sdkmetric.ManualReader, clients) {
+	reader := sdkmetric.NewManualReader()
+	m, err := otel.NewMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverInterceptors := connect.WithInterceptors(m.UnaryServerInterceptor(), m.StreamServerInterceptor())
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		return connect.NewResponse(wrapperspb.String(req.Msg.Value + req.Msg.Value)), nil
+	}, serverInterceptors))
+	mux.Handle(failProcedure, connect.NewUnaryHandler(failProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		return nil, connect.NewError(connect.CodeUnavailable, errors.New("down"))
+	}, serverInterceptors))
+	mux.Handle(chatProcedure, connect.NewBidiStreamHandler(chatProcedure, func(ctx context.Context, stream *connect.BidiStream[wrapperspb.StringValue, wrapperspb.StringValue]) error {
+		// Messages are received and sent concurrently.
+		received := make(chan *wrapperspb.StringValue)
+		go func() {
+			defer close(received)
+			for {
+				msg, err := stream.Receive()
+				if err != nil {
+					return
+				}
+				received <- msg
+			}
+		}()
+		for msg := range received {
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, serverInterceptors))
+	// Bidi streams need HTTP/2.
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	clientInterceptors := connect.WithInterceptors(m.UnaryClientInterceptor(), m.StreamClientInterceptor())
+	newClient := func(procedure string) *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue] {
+		return connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+procedure, clientInterceptors)
+	}
+	return reader, clients{echo: newClient(echoProcedure), fail: newClient(failProcedure), chat: newClient(chatProcedure)}
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		if sm.Scope.Name != otel.ScopeName {
+			t.Fatalf("unexpected scope %q", sm.Scope.Name)
+		}
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestMetrics_Unary(t *testing.T) {
+	reader, c := setup(t)
+	req := wrapperspb.String("ping")
+	if _, err := c.echo.CallUnary(context.Background(), connect.NewRequest(req)); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := collect(t, reader)
+	for _, name := range []string{
+		"rpc.server.duration", "rpc.server.request.size", "rpc.server.response.size", "rpc.server.requests_per_rpc", "rpc.server.responses_per_rpc",
+		"rpc.client.duration", "rpc.client.request.size", "rpc.client.response.size", "rpc.client.requests_per_rpc", "rpc.client.responses_per_rpc",
+	} {
+		if _, ok := metrics[name]; !ok {
+			t.Errorf("missing metric %q", name)
+		}
+	}
+
+	wantAttrs := attribute.NewSet(
+		otel.RPCSystemKey.String("connect_rpc"),
+		otel.RPCServiceKey.String("test.v1.TestService"),
+		otel.RPCMethodKey.String("Echo"),
+	)
+	requestSize := metrics["rpc.server.request.size"].(metricdata.Histogram[int64]).DataPoints
+	if len(requestSize) != 1 || !requestSize[0].Attributes.Equals(&wantAttrs) {
+		t.Fatalf("unexpected request size data points %+v", requestSize)
+	}
+	if got, want := requestSize[0].Sum, int64(proto.Size(req)); got != want {
+		t.Errorf("expected request size %d, got %d", want, got)
+	}
+	for _, side := range []string{"server", "client"} {
+		requestSize := metrics["rpc."+side+".request.size"].(metricdata.Histogram[int64]).DataPoints
+		if got, want := requestSize[0].Sum, int64(proto.Size(req)); got != want {
+			t.Errorf("expected %s request size %d, got %d", side, want, got)
+		}
+		responseSize := metrics["rpc."+side+".response.size"].(metricdata.Histogram[int64]).DataPoints
+		if got, want := responseSize[0].Sum, int64(proto.Size(wrapperspb.String("pingping"))); got != want {
+			t.Errorf("expected %s response size %d, got %d", side, want, got)
+		}
+	}
+	for _, name := range []string{"rpc.server.requests_per_rpc", "rpc.client.responses_per_rpc"} {
+		dps := metrics[name].(metricdata.Histogram[int64]).DataPoints
+		if len(dps) != 1 || dps[0].Count != 1 || dps[0].Sum != 1 {
+			t.Errorf("expected one message per rpc for %q, got %+v", name, dps)
+		}
+	}
+}
+
+func TestMetrics_ErrorCode(t *testing.T) {
+	reader, c := setup(t)
+	if _, err := c.fail.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping"))); err == nil {
+		t.Fatal("expected error")
+	}
+
+	metrics := collect(t, reader)
+	for _, name := range []string{"rpc.server.duration", "rpc.client.duration"} {
+		dps := metrics[name].(metricdata.Histogram[float64]).DataPoints
+		if len(dps) != 1 {
+			t.Fatalf("expected one data point for %q, got %d", name, len(dps))
+		}
+		code, ok := dps[0].Attributes.Value(otel.RPCConnectRPCErrorCodeKey)
+		if !ok || code.AsString() != "unavailable" {
+			t.Errorf("expected unavailable error code on %q, got %v", name, code.AsString())
+		}
+	}
+}
+
+func TestMetrics_StreamSizes(t *testing.T) {
+	reader, c := setup(t)
+	const n = 3
+	msg := wrapperspb.String("ping")
+
+	stream := c.chat.CallBidiStream(context.Background())
+	for i := 0; i < n; i++ {
+		if err := stream.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stream.Receive(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stream.CloseRequest(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Receive(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the end of the stream, got %v", err)
+	}
+	if err := stream.CloseResponse(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every message of the stream is measured, on both sides.
+	metrics := collect(t, reader)
+	for _, name := range []string{
+		"rpc.server.request.size", "rpc.server.response.size",
+		"rpc.client.request.size", "rpc.client.response.size",
+	} {
+		dps := metrics[name].(metricdata.Histogram[int64]).DataPoints
+		if len(dps) != 1 || dps[0].Count != n || dps[0].Sum != n*int64(proto.Size(msg)) {
+			t.Errorf("expected %d messages of %d bytes for %q, got %+v", n, proto.Size(msg), name, dps)
+		}
+	}
+}
+
+func TestMetrics_Bidi(t *testing.T) {
+	reader, c := setup(t)
+
+	// The stream is abandoned while sending and receiving concurrently: it is finished when its context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := c.chat.CallBidiStream(ctx)
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for stream.Send(wrapperspb.String("ping")) == nil {
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		if _, err := stream.Receive(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel()
+	<-sent
+
+	// The call is reported asynchronously once its context is done.
+	var metrics map[string]metricdata.Aggregation
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if metrics = collect(t, reader); metrics["rpc.client.duration"] != nil {
+			break
+		}
+	}
+	for _, name := range []string{"rpc.client.requests_per_rpc", "rpc.client.responses_per_rpc"} {
+		dps := metrics[name].(metricdata.Histogram[int64]).DataPoints
+		if len(dps) != 1 || dps[0].Count != 1 {
+			t.Errorf("expected one data point for %q, got %+v", name, dps)
+		}
+	}
+	dps := metrics["rpc.client.duration"].(metricdata.Histogram[float64]).DataPoints
+	if code, ok := dps[0].Attributes.Value(otel.RPCConnectRPCErrorCodeKey); !ok || code.AsString() != "canceled" {
+		t.Errorf("expected canceled error code, got %v", code.AsString())
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package otel
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// ScopeName is the instrumentation scope name of the meter used by Metrics.
+	ScopeName = "github.com/svrana/go-connect-middleware/interceptors/metrics/otel"
+)
+
+type options struct {
+	attributes []attribute.KeyValue
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithAttributes adds the given attributes to every recorded measurement, e.g. to identify the server.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(o *options) {
+		o.attributes = attrs
+	}
+}
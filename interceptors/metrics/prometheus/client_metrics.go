@@ -0,0 +1,120 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package prometheus
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+var _ interceptors.ClientReportable = &ClientMetrics{}
+
+// ClientMetrics represents a collection of metrics to be registered on a
+// Prometheus metrics registry for a connect client.
+type ClientMetrics struct {
+	clientStartedCounter      *prometheus.CounterVec
+	clientHandledCounter      *prometheus.CounterVec
+	clientStreamMsgReceived   *prometheus.CounterVec
+	clientStreamMsgSent       *prometheus.CounterVec
+	clientHandledHistogram    *prometheus.HistogramVec
+	clientStreamRecvHistogram *prometheus.HistogramVec
+	clientStreamSendHistogram *prometheus.HistogramVec
+}
+
+// NewClientMetrics returns a new ClientMetrics object.
+// NOTE: Remember to register ClientMetrics object using prometheus registry
+// e.g. prometheus.MustRegister(myClientMetrics), or any custom prometheus.Registerer.
+func NewClientMetrics(opts ...ClientMetricsOption) *ClientMetrics {
+	var config clientMetricsConfig
+	config.apply(opts)
+	return &ClientMetrics{
+		clientStartedCounter: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "connect_client_started_total",
+				Help: "Total number of RPCs started on the client.",
+			}), []string{"connect_type", "connect_service", "connect_method"}),
+		clientHandledCounter: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "connect_client_handled_total",
+				Help: "Total number of RPCs completed by the client, regardless of success or failure.",
+			}), []string{"connect_type", "connect_service", "connect_method", "connect_code"}),
+		clientStreamMsgReceived: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "connect_client_msg_received_total",
+				Help: "Total number of RPC stream messages received by the client.",
+			}), []string{"connect_type", "connect_service", "connect_method"}),
+		clientStreamMsgSent: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "connect_client_msg_sent_total",
+				Help: "Total number of RPC stream messages sent by the client.",
+			}), []string{"connect_type", "connect_service", "connect_method"}),
+		clientHandledHistogram:    config.clientHandledHistogram,
+		clientStreamRecvHistogram: config.clientStreamRecvHistogram,
+		clientStreamSendHistogram: config.clientStreamSendHistogram,
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector to the provided channel and returns once
+// the last descriptor has been sent.
+func (m *ClientMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.clientStartedCounter.Describe(ch)
+	m.clientHandledCounter.Describe(ch)
+	m.clientStreamMsgReceived.Describe(ch)
+	m.clientStreamMsgSent.Describe(ch)
+	if m.clientHandledHistogram != nil {
+		m.clientHandledHistogram.Describe(ch)
+	}
+	if m.clientStreamRecvHistogram != nil {
+		m.clientStreamRecvHistogram.Describe(ch)
+	}
+	if m.clientStreamSendHistogram != nil {
+		m.clientStreamSendHistogram.Describe(ch)
+	}
+}
+
+// Collect is called by the Prometheus registry when collecting
+// metrics. The implementation sends each collected metric via the
+// provided channel and returns once the last metric has been sent.
+func (m *ClientMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.clientStartedCounter.Collect(ch)
+	m.clientHandledCounter.Collect(ch)
+	m.clientStreamMsgReceived.Collect(ch)
+	m.clientStreamMsgSent.Collect(ch)
+	if m.clientHandledHistogram != nil {
+		m.clientHandledHistogram.Collect(ch)
+	}
+	if m.clientStreamRecvHistogram != nil {
+		m.clientStreamRecvHistogram.Collect(ch)
+	}
+	if m.clientStreamSendHistogram != nil {
+		m.clientStreamSendHistogram.Collect(ch)
+	}
+}
+
+// ClientReporter implements interceptors.ClientReportable.
+func (m *ClientMetrics) ClientReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	r := &reporter{
+		clientMetrics: m,
+		typ:           interceptors.ConnectTypeOf(meta.Typ),
+		service:       meta.Service,
+		method:        meta.Method,
+	}
+	r.clientMetrics.clientStartedCounter.WithLabelValues(string(r.typ), r.service, r.method).Inc()
+	return r, ctx
+}
+
+// UnaryClientInterceptor is a connect client-side interceptor that provides Prometheus monitoring for Unary RPCs.
+func (m *ClientMetrics) UnaryClientInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryClientInterceptor(m)
+}
+
+// StreamClientInterceptor is a connect client-side interceptor that provides Prometheus monitoring for Streaming RPCs.
+func (m *ClientMetrics) StreamClientInterceptor() connect.Interceptor {
+	return interceptors.StreamClientInterceptor(m)
+}
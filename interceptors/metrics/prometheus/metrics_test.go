@@ -0,0 +1,282 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+	connectprom "github.com/svrana/go-connect-middleware/interceptors/metrics/prometheus"
+)
+
+const (
+	echoProcedure   = "/test.v1.TestService/Echo"
+	failProcedure   = "/test.v1.TestService/Fail"
+	streamProcedure = "/test.v1.TestService/Stream"
+)
+
+// newServer returns a server of an Echo and a Fail unary procedures and of a Stream server stream procedure sending
+// its request twice, reported to m.
+func newServer(t *testing.T, m *connectprom.ServerMetrics) *httptest.Server {
+	opts := connect.WithInterceptors(m.UnaryServerInterceptor(), m.StreamServerInterceptor())
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		return connect.NewResponse(req.Msg), nil
+	}, opts))
+	mux.Handle(failProcedure, connect.NewUnaryHandler(failProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("not found"))
+	}, opts))
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+		for i := 0; i < 2; i++ {
+			if err := stream.Send(req.Msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, opts))
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// callAll calls every procedure of the server once.
+func callAll(t *testing.T, url string, opts ...connect.ClientOption) {
+	ctx := context.Background()
+	echo := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](http.DefaultClient, url+echoProcedure, opts...)
+	if _, err := echo.CallUnary(ctx, connect.NewRequest(wrapperspb.String("hi"))); err != nil {
+		t.Fatal(err)
+	}
+	fail := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](http.DefaultClient, url+failProcedure, opts...)
+	if _, err := fail.CallUnary(ctx, connect.NewRequest(wrapperspb.String("hi"))); connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("got %v, want not found", err)
+	}
+	streamClient := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](http.DefaultClient, url+streamProcedure, opts...)
+	stream, err := streamClient.CallServerStream(ctx, connect.NewRequest(wrapperspb.String("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Receive() {
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// value returns the value of the counter, or the sample count of the histogram, of the given name and labels
+// gathered from g, or -1 if there is none.
+func value(t *testing.T, g prometheus.Gatherer, name string, labels map[string]string) float64 {
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			got := map[string]string{}
+			for _, l := range m.GetLabel() {
+				got[l.GetName()] = l.GetValue()
+			}
+			for k, v := range labels {
+				if got[k] != v {
+					continue metrics
+				}
+			}
+			if h := m.GetHistogram(); h != nil {
+				return float64(h.GetSampleCount())
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return -1
+}
+
+type counterTest struct {
+	name   string
+	typ    string
+	method string
+	code   string
+	want   float64
+}
+
+func checkCounters(t *testing.T, g prometheus.Gatherer, tests []counterTest) {
+	t.Helper()
+	for _, tc := range tests {
+		labels := map[string]string{"connect_type": tc.typ, "connect_service": "test.v1.TestService", "connect_method": tc.method}
+		if tc.code != "" {
+			labels["connect_code"] = tc.code
+		}
+		if got := value(t, g, tc.name, labels); got != tc.want {
+			t.Errorf("%s%v = %v, want %v", tc.name, labels, got, tc.want)
+		}
+	}
+}
+
+func TestServerMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := connectprom.NewServerMetrics()
+	reg.MustRegister(m)
+	s := newServer(t, m)
+
+	callAll(t, s.URL)
+
+	// Counters never incremented are not gathered.
+	checkCounters(t, reg, []counterTest{
+		{name: "connect_server_started_total", typ: "unary", method: "Echo", want: 1},
+		{name: "connect_server_started_total", typ: "unary", method: "Fail", want: 1},
+		{name: "connect_server_started_total", typ: "server_stream", method: "Stream", want: 1},
+		{name: "connect_server_handled_total", typ: "unary", method: "Echo", code: "ok", want: 1},
+		{name: "connect_server_handled_total", typ: "unary", method: "Fail", code: "not_found", want: 1},
+		{name: "connect_server_handled_total", typ: "server_stream", method: "Stream", code: "ok", want: 1},
+		{name: "connect_server_msg_received_total", typ: "unary", method: "Echo", want: 1},
+		{name: "connect_server_msg_received_total", typ: "unary", method: "Fail", want: 1},
+		{name: "connect_server_msg_received_total", typ: "server_stream", method: "Stream", want: 1},
+		{name: "connect_server_msg_sent_total", typ: "unary", method: "Echo", want: 1},
+		{name: "connect_server_msg_sent_total", typ: "unary", method: "Fail", want: -1},
+		{name: "connect_server_msg_sent_total", typ: "server_stream", method: "Stream", want: 2},
+	})
+}
+
+func TestClientMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := connectprom.NewClientMetrics()
+	reg.MustRegister(m)
+	s := newServer(t, connectprom.NewServerMetrics())
+
+	callAll(t, s.URL, connect.WithInterceptors(m.UnaryClientInterceptor(), m.StreamClientInterceptor()))
+
+	checkCounters(t, reg, []counterTest{
+		{name: "connect_client_started_total", typ: "unary", method: "Echo", want: 1},
+		{name: "connect_client_started_total", typ: "unary", method: "Fail", want: 1},
+		{name: "connect_client_started_total", typ: "server_stream", method: "Stream", want: 1},
+		{name: "connect_client_handled_total", typ: "unary", method: "Echo", code: "ok", want: 1},
+		{name: "connect_client_handled_total", typ: "unary", method: "Fail", code: "not_found", want: 1},
+		{name: "connect_client_handled_total", typ: "server_stream", method: "Stream", code: "ok", want: 1},
+		// The request of failed calls was sent all the same.
+		{name: "connect_client_msg_sent_total", typ: "unary", method: "Echo", want: 1},
+		{name: "connect_client_msg_sent_total", typ: "unary", method: "Fail", want: 1},
+		{name: "connect_client_msg_sent_total", typ: "server_stream", method: "Stream", want: 1},
+		{name: "connect_client_msg_received_total", typ: "unary", method: "Echo", want: 1},
+		{name: "connect_client_msg_received_total", typ: "unary", method: "Fail", want: -1},
+		{name: "connect_client_msg_received_total", typ: "server_stream", method: "Stream", want: 2},
+	})
+}
+
+func TestCustomRegisterer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	server := connectprom.NewServerMetrics(connectprom.WithServerCounterOptions(connectprom.WithConstLabels(prometheus.Labels{"side": "server"})))
+	client := connectprom.NewClientMetrics()
+	prometheus.WrapRegistererWithPrefix("app_", reg).MustRegister(server, client)
+	s := newServer(t, server)
+
+	callAll(t, s.URL, connect.WithInterceptors(client.UnaryClientInterceptor(), client.StreamClientInterceptor()))
+
+	checkCounters(t, reg, []counterTest{
+		{name: "app_connect_server_started_total", typ: "unary", method: "Echo", want: 1},
+		{name: "app_connect_client_started_total", typ: "unary", method: "Echo", want: 1},
+	})
+	if got := value(t, reg, "app_connect_server_started_total", map[string]string{"side": "server"}); got != 1 {
+		t.Errorf("server metrics with const label = %v, want 1", got)
+	}
+	if got := value(t, prometheus.DefaultGatherer, "connect_server_started_total", nil); got != -1 {
+		t.Errorf("metrics registered on the default registry, want them on the custom one only")
+	}
+}
+
+func TestClientHistograms(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := connectprom.NewClientMetrics(
+		connectprom.WithClientHandlingTimeHistogram(),
+		connectprom.WithClientStreamSendHistogram(),
+		connectprom.WithClientStreamRecvHistogram(),
+	)
+	reg.MustRegister(m)
+	s := newServer(t, connectprom.NewServerMetrics())
+
+	callAll(t, s.URL, connect.WithInterceptors(m.UnaryClientInterceptor(), m.StreamClientInterceptor()))
+
+	// Unary calls only have a handling time.
+	checkCounters(t, reg, []counterTest{
+		{name: "connect_client_handling_seconds", typ: "unary", method: "Echo", want: 1},
+		{name: "connect_client_handling_seconds", typ: "server_stream", method: "Stream", want: 1},
+		{name: "connect_client_msg_send_handling_seconds", typ: "unary", method: "Echo", want: -1},
+		{name: "connect_client_msg_send_handling_seconds", typ: "server_stream", method: "Stream", want: 1},
+		{name: "connect_client_msg_recv_handling_seconds", typ: "unary", method: "Echo", want: -1},
+		{name: "connect_client_msg_recv_handling_seconds", typ: "server_stream", method: "Stream", want: 2},
+	})
+}
+
+func init() {
+	// A test.v1.TestService of a unary Check and a server stream Watch methods.
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/v1/test.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/wrappers.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("TestService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Check"), InputType: proto.String(".google.protobuf.StringValue"), OutputType: proto.String(".google.protobuf.StringValue")},
+				{Name: proto.String("Watch"), InputType: proto.String(".google.protobuf.StringValue"), OutputType: proto.String(".google.protobuf.StringValue"), ServerStreaming: proto.Bool(true)},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		panic(err)
+	}
+}
+
+func TestInitializeMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := connectprom.NewServerMetrics(connectprom.WithServerHandlingTimeHistogram())
+	reg.MustRegister(m)
+
+	if err := m.InitializeMetrics("/test.v1.TestService/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.InitializeMetrics("/unknown.v1.Service/"); err == nil {
+		t.Fatal("want an error for an unknown service")
+	}
+
+	for _, method := range []struct{ name, typ string }{{"Check", "unary"}, {"Watch", "server_stream"}} {
+		labels := map[string]string{"connect_type": method.typ, "connect_service": "test.v1.TestService", "connect_method": method.name}
+		for _, name := range []string{
+			"connect_server_started_total",
+			"connect_server_msg_received_total",
+			"connect_server_msg_sent_total",
+			"connect_server_handling_seconds",
+		} {
+			if got := value(t, reg, name, labels); got != 0 {
+				t.Errorf("%s%v = %v, want 0", name, labels, got)
+			}
+		}
+		codes := []string{"ok"}
+		for _, code := range interceptors.AllCodes {
+			codes = append(codes, code.String())
+		}
+		for _, code := range codes {
+			labels["connect_code"] = code
+			if got := value(t, reg, "connect_server_handled_total", labels); got != 0 {
+				t.Errorf("connect_server_handled_total%v = %v, want 0", labels, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package prometheus
+
+import (
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+type reporter struct {
+	clientMetrics *ClientMetrics
+	serverMetrics *ServerMetrics
+
+	typ             interceptors.ConnectType
+	service, method string
+}
+
+func (r *reporter) PostCall(err error, rpcDuration time.Duration) {
+	code := interceptors.CodeString(err)
+	if r.serverMetrics != nil {
+		r.serverMetrics.serverHandledCounter.WithLabelValues(string(r.typ), r.service, r.method, code).Inc()
+		if r.serverMetrics.serverHandledHistogram != nil {
+			r.serverMetrics.serverHandledHistogram.WithLabelValues(string(r.typ), r.service, r.method).Observe(rpcDuration.Seconds())
+		}
+		return
+	}
+	r.clientMetrics.clientHandledCounter.WithLabelValues(string(r.typ), r.service, r.method, code).Inc()
+	if r.clientMetrics.clientHandledHistogram != nil {
+		r.clientMetrics.clientHandledHistogram.WithLabelValues(string(r.typ), r.service, r.method).Observe(rpcDuration.Seconds())
+	}
+}
+
+func (r *reporter) PostMsgSend(_ connect.AnyResponse, err error, sendDuration time.Duration) {
+	if r.serverMetrics != nil {
+		if err == nil {
+			r.serverMetrics.serverStreamMsgSent.WithLabelValues(string(r.typ), r.service, r.method).Inc()
+		}
+		return
+	}
+	// The error of unary calls is the one of the whole call, which was sent anyway.
+	if err != nil && r.typ != interceptors.Unary {
+		return
+	}
+	r.clientMetrics.clientStreamMsgSent.WithLabelValues(string(r.typ), r.service, r.method).Inc()
+	// The duration of unary calls is the one of the whole call, already in the handling histogram.
+	if r.clientMetrics.clientStreamSendHistogram != nil && r.typ != interceptors.Unary {
+		r.clientMetrics.clientStreamSendHistogram.WithLabelValues(string(r.typ), r.service, r.method).Observe(sendDuration.Seconds())
+	}
+}
+
+func (r *reporter) PostMsgReceive(_ connect.AnyRequest, err error, recvDuration time.Duration) {
+	if err != nil {
+		return
+	}
+	if r.serverMetrics != nil {
+		r.serverMetrics.serverStreamMsgReceived.WithLabelValues(string(r.typ), r.service, r.method).Inc()
+		return
+	}
+	r.clientMetrics.clientStreamMsgReceived.WithLabelValues(string(r.typ), r.service, r.method).Inc()
+	if r.clientMetrics.clientStreamRecvHistogram != nil && r.typ != interceptors.Unary {
+		r.clientMetrics.clientStreamRecvHistogram.WithLabelValues(string(r.typ), r.service, r.method).Observe(recvDuration.Seconds())
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+var _ interceptors.ServerReportable = &ServerMetrics{}
+
+// ServerMetrics represents a collection of metrics to be registered on a
+// Prometheus metrics registry for a connect server.
+type ServerMetrics struct {
+	serverStartedCounter    *prometheus.CounterVec
+	serverHandledCounter    *prometheus.CounterVec
+	serverStreamMsgReceived *prometheus.CounterVec
+	serverStreamMsgSent     *prometheus.CounterVec
+	// serverHandledHistogram can be nil.
+	serverHandledHistogram *prometheus.HistogramVec
+}
+
+// NewServerMetrics returns a new ServerMetrics object that has server interceptor methods.
+// NOTE: Remember to register ServerMetrics object by using prometheus registry
+// e.g. prometheus.MustRegister(myServerMetrics).
+func NewServerMetrics(opts ...ServerMetricsOption) *ServerMetrics {
+	var config serverMetricsConfig
+	config.apply(opts)
+	return &ServerMetrics{
+		serverStartedCounter: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "connect_server_started_total",
+				Help: "Total number of RPCs started on the server.",
+			}), []string{"connect_type", "connect_service", "connect_method"}),
+		serverHandledCounter: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "connect_server_handled_total",
+				Help: "Total number of RPCs completed on the server, regardless of success or failure.",
+			}), []string{"connect_type", "connect_service", "connect_method", "connect_code"}),
+		serverStreamMsgReceived: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "connect_server_msg_received_total",
+				Help: "Total number of RPC messages received on the server.",
+			}), []string{"connect_type", "connect_service", "connect_method"}),
+		serverStreamMsgSent: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "connect_server_msg_sent_total",
+				Help: "Total number of RPC messages sent by the server.",
+			}), []string{"connect_type", "connect_service", "connect_method"}),
+		serverHandledHistogram: config.serverHandledHistogram,
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector to the provided channel and returns once
+// the last descriptor has been sent.
+func (m *ServerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.serverStartedCounter.Describe(ch)
+	m.serverHandledCounter.Describe(ch)
+	m.serverStreamMsgReceived.Describe(ch)
+	m.serverStreamMsgSent.Describe(ch)
+	if m.serverHandledHistogram != nil {
+		m.serverHandledHistogram.Describe(ch)
+	}
+}
+
+// Collect is called by the Prometheus registry when collecting
+// metrics. The implementation sends each collected metric via the
+// provided channel and returns once the last metric has been sent.
+func (m *ServerMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.serverStartedCounter.Collect(ch)
+	m.serverHandledCounter.Collect(ch)
+	m.serverStreamMsgReceived.Collect(ch)
+	m.serverStreamMsgSent.Collect(ch)
+	if m.serverHandledHistogram != nil {
+		m.serverHandledHistogram.Collect(ch)
+	}
+}
+
+// InitializeMetrics initializes all metrics, with their appropriate null
+// value, for all connect methods of the given services. This is useful, to
+// ensure that all metrics exist when collecting and querying.
+//
+// Services are given by the path returned by the generated New...ServiceHandler
+// functions (e.g. "/ping.v1.PingService/"), and looked up in the global protobuf registry.
+func (m *ServerMetrics) InitializeMetrics(servicePaths ...string) error {
+	for _, path := range servicePaths {
+		name := protoreflect.FullName(strings.Trim(path, "/"))
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+		if err != nil {
+			return fmt.Errorf("looking up service %q: %w", name, err)
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return fmt.Errorf("%q is not a service", name)
+		}
+		m.InitializeMetricsForService(sd)
+	}
+	return nil
+}
+
+// InitializeMetricsForService is like InitializeMetrics, for the given service descriptor.
+func (m *ServerMetrics) InitializeMetricsForService(sd protoreflect.ServiceDescriptor) {
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		m.preRegisterMethod(string(sd.FullName()), string(md.Name()), typeFromMethodInfo(md.IsStreamingClient(), md.IsStreamingServer()))
+	}
+}
+
+// preRegisterMethod is invoked on Register of a Server, allowing all gRPC services labels to be pre-populated.
+func (m *ServerMetrics) preRegisterMethod(serviceName, methodName string, typ interceptors.ConnectType) {
+	// These are just references (no increments), as just referencing will create the labels but not set values.
+	_, _ = m.serverStartedCounter.GetMetricWithLabelValues(string(typ), serviceName, methodName)
+	_, _ = m.serverStreamMsgReceived.GetMetricWithLabelValues(string(typ), serviceName, methodName)
+	_, _ = m.serverStreamMsgSent.GetMetricWithLabelValues(string(typ), serviceName, methodName)
+	if m.serverHandledHistogram != nil {
+		_, _ = m.serverHandledHistogram.GetMetricWithLabelValues(string(typ), serviceName, methodName)
+	}
+	_, _ = m.serverHandledCounter.GetMetricWithLabelValues(string(typ), serviceName, methodName, interceptors.CodeOK)
+	for _, code := range interceptors.AllCodes {
+		_, _ = m.serverHandledCounter.GetMetricWithLabelValues(string(typ), serviceName, methodName, code.String())
+	}
+}
+
+// ServerReporter implements interceptors.ServerReportable.
+func (m *ServerMetrics) ServerReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	r := &reporter{
+		serverMetrics: m,
+		typ:           interceptors.ConnectTypeOf(meta.Typ),
+		service:       meta.Service,
+		method:        meta.Method,
+	}
+	r.serverMetrics.serverStartedCounter.WithLabelValues(string(r.typ), r.service, r.method).Inc()
+	return r, ctx
+}
+
+// UnaryServerInterceptor is a connect server-side interceptor that provides Prometheus monitoring for Unary RPCs.
+func (m *ServerMetrics) UnaryServerInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryServerInterceptor(m)
+}
+
+// StreamServerInterceptor is a connect server-side interceptor that provides Prometheus monitoring for Streaming RPCs.
+func (m *ServerMetrics) StreamServerInterceptor() connect.Interceptor {
+	return interceptors.StreamServerInterceptor(m)
+}
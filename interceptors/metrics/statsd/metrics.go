@@ -0,0 +1,268 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package statsd
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+// ErrClosed is returned by Close if the Metrics were already closed.
+var ErrClosed = errors.New("statsd: metrics closed")
+
+var (
+	_ interceptors.ServerReportable = &Metrics{}
+	_ interceptors.ClientReportable = &Metrics{}
+)
+
+// Metrics sends per-call metrics to a StatsD agent, using the DogStatsD format for tags:
+//
+//   - {prefix}{server,client}.started, .handled, .msg_received and .msg_sent counts,
+//   - {prefix}{server,client}.handling_time timing, in milliseconds,
+//   - {prefix}{server,client}.request.size and .response.size distributions, in bytes.
+//
+// Every metric is tagged with connect_type, connect_service and connect_method, and handled and handling_time
+// also with connect_code. The size distributions get one value per protobuf message, so streams report each of
+// their messages.
+//
+// Metrics are queued and batched in packets by a background goroutine, reporting never blocks: metrics are
+// dropped if the queue is full (see Dropped). Close must be called to flush the last metrics.
+type Metrics struct {
+	opts *options
+	conn net.Conn
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+// New returns new Metrics sending to the agent listening at addr, either "host:port" or "udp://host:port" for
+// UDP, or "unix:///path/to/socket" for a Unix datagram socket.
+func New(addr string, opts ...Option) (*Metrics, error) {
+	o := evaluateOptions(opts)
+	network, address := "udp", strings.TrimPrefix(addr, "udp://")
+	if strings.HasPrefix(addr, "unix://") {
+		network, address = "unixgram", strings.TrimPrefix(addr, "unix://")
+	}
+	if o.maxPacketSize <= 0 {
+		o.maxPacketSize = DefaultUDPPacketSize
+		if network == "unixgram" {
+			o.maxPacketSize = DefaultUnixPacketSize
+		}
+	}
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	m := &Metrics{
+		opts:  o,
+		conn:  conn,
+		queue: make(chan []byte, o.queueSize),
+		done:  make(chan struct{}),
+	}
+	go m.run()
+	return m, nil
+}
+
+// Dropped returns the number of metrics dropped because the queue was full.
+func (m *Metrics) Dropped() uint64 {
+	return m.dropped.Load()
+}
+
+// Close sends the metrics still queued and closes the connection to the agent. Metrics reported afterwards are
+// dropped.
+func (m *Metrics) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrClosed
+	}
+	m.closed = true
+	close(m.queue)
+	m.mu.Unlock()
+
+	<-m.done
+	return m.conn.Close()
+}
+
+// run batches queued metrics in packets, sent when full or every flush interval.
+func (m *Metrics) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.opts.flushInterval)
+	defer ticker.Stop()
+
+	packet := make([]byte, 0, m.opts.maxPacketSize)
+	flush := func() {
+		if len(packet) > 0 {
+			// Write errors are ignored, like the agent being down: metrics must never fail calls.
+			_, _ = m.conn.Write(packet)
+			packet = packet[:0]
+		}
+	}
+	for {
+		select {
+		case line, ok := <-m.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if len(packet) > 0 && len(packet)+1+len(line) > m.opts.maxPacketSize {
+				flush()
+			}
+			if len(packet) > 0 {
+				packet = append(packet, '\n')
+			}
+			packet = append(packet, line...)
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send formats and queues a metric, subject to the sample rate, e.g. "connect.server.handled:1|c|#connect_code:ok".
+func (m *Metrics) send(name string, value float64, typ string, tags string) {
+	rate := m.opts.sampleRate
+	if rate < 1 && rand.Float64() >= rate {
+		return
+	}
+
+	line := make([]byte, 0, len(m.opts.prefix)+len(name)+len(tags)+32)
+	line = append(line, m.opts.prefix...)
+	line = append(line, name...)
+	line = append(line, ':')
+	line = strconv.AppendFloat(line, value, 'f', -1, 64)
+	line = append(line, '|')
+	line = append(line, typ...)
+	if rate < 1 {
+		line = append(line, "|@"...)
+		line = strconv.AppendFloat(line, rate, 'f', -1, 64)
+	}
+	if tags != "" {
+		line = append(line, "|#"...)
+		line = append(line, tags...)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		m.dropped.Add(1)
+		return
+	}
+	select {
+	case m.queue <- line:
+	default:
+		m.dropped.Add(1)
+	}
+}
+
+// ServerReporter implements interceptors.ServerReportable.
+func (m *Metrics) ServerReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return m.newReporter(meta, "server."), ctx
+}
+
+// ClientReporter implements interceptors.ClientReportable.
+func (m *Metrics) ClientReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return m.newReporter(meta, "client."), ctx
+}
+
+func (m *Metrics) newReporter(meta interceptors.CallMeta, side string) *reporter {
+	tags := make([]string, 0, len(m.opts.tags)+3)
+	tags = append(tags, m.opts.tags...)
+	tags = append(tags,
+		"connect_type:"+string(interceptors.ConnectTypeOf(meta.Typ)),
+		"connect_service:"+meta.Service,
+		"connect_method:"+meta.Method,
+	)
+	r := &reporter{metrics: m, callMeta: meta, side: side, tags: strings.Join(tags, ",")}
+	m.send(side+"started", 1, "c", r.tags)
+	return r
+}
+
+// UnaryServerInterceptor is a connect server-side interceptor that sends StatsD metrics for Unary RPCs.
+func (m *Metrics) UnaryServerInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryServerInterceptor(m)
+}
+
+// StreamServerInterceptor is a connect server-side interceptor that sends StatsD metrics for Streaming RPCs.
+func (m *Metrics) StreamServerInterceptor() connect.Interceptor {
+	return interceptors.StreamServerInterceptor(m)
+}
+
+// UnaryClientInterceptor is a connect client-side interceptor that sends StatsD metrics for Unary RPCs.
+func (m *Metrics) UnaryClientInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryClientInterceptor(m)
+}
+
+// StreamClientInterceptor is a connect client-side interceptor that sends StatsD metrics for Streaming RPCs.
+func (m *Metrics) StreamClientInterceptor() connect.Interceptor {
+	return interceptors.StreamClientInterceptor(m)
+}
+
+type reporter struct {
+	metrics  *Metrics
+	callMeta interceptors.CallMeta
+	// side is the metric name prefix of the side of the call, "server." or "client.".
+	side string
+	tags string
+}
+
+func (r *reporter) PostCall(err error, rpcDuration time.Duration) {
+	tags := r.tags + ",connect_code:" + interceptors.CodeString(err)
+	r.metrics.send(r.side+"handled", 1, "c", tags)
+	r.metrics.send(r.side+"handling_time", float64(rpcDuration)/float64(time.Millisecond), "ms", tags)
+}
+
+func (r *reporter) PostMsgSend(res connect.AnyResponse, err error, _ time.Duration) {
+	// The error of unary client calls is the one of the whole call, whose request was sent anyway.
+	if err != nil && !(r.callMeta.IsClient && r.callMeta.Typ == connect.StreamTypeUnary) {
+		return
+	}
+	r.metrics.send(r.side+"msg_sent", 1, "c", r.tags)
+	if res == nil {
+		return
+	}
+	// Clients send requests.
+	if r.callMeta.IsClient {
+		r.sendSize("request.size", res.Any())
+	} else {
+		r.sendSize("response.size", res.Any())
+	}
+}
+
+func (r *reporter) PostMsgReceive(req connect.AnyRequest, err error, _ time.Duration) {
+	if err != nil {
+		return
+	}
+	r.metrics.send(r.side+"msg_received", 1, "c", r.tags)
+	if req == nil {
+		return
+	}
+	// Clients receive responses.
+	if r.callMeta.IsClient {
+		r.sendSize("response.size", req.Any())
+	} else {
+		r.sendSize("request.size", req.Any())
+	}
+}
+
+func (r *reporter) sendSize(name string, msg any) {
+	if m, ok := msg.(proto.Message); ok {
+		r.metrics.send(r.side+name, float64(proto.Size(m)), "d", r.tags)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package statsd
+
+import (
+	"time"
+)
+
+const (
+	// DefaultUDPPacketSize fits in the usual 1500 bytes Ethernet MTU, minus IP and UDP headers.
+	DefaultUDPPacketSize = 1432
+	// DefaultUnixPacketSize is the packet size used over Unix datagram sockets, which have no MTU.
+	DefaultUnixPacketSize = 8192
+)
+
+type options struct {
+	prefix        string
+	tags          []string
+	sampleRate    float64
+	maxPacketSize int
+	flushInterval time.Duration
+	queueSize     int
+}
+
+var defaultOptions = &options{
+	prefix:        "connect.",
+	sampleRate:    1,
+	flushInterval: 100 * time.Millisecond,
+	queueSize:     4096,
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithPrefix sets the prefix of every metric name. Defaults to "connect.", e.g. "connect.server.handled".
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithTags adds the given tags, formatted as "key:value", to every metric, e.g. "env:prod".
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithSampleRate sets the rate, between 0 and 1, at which metrics are sent. The agent scales counts back up
+// using the rate sent along with the metric. Defaults to 1, i.e. every metric is sent.
+func WithSampleRate(rate float64) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithMaxPacketSize sets the maximum size of the packets metrics are batched in. Defaults to DefaultUDPPacketSize
+// over UDP and DefaultUnixPacketSize over Unix datagram sockets.
+func WithMaxPacketSize(size int) Option {
+	return func(o *options) {
+		o.maxPacketSize = size
+	}
+}
+
+// WithFlushInterval sets the maximum time metrics are buffered before being sent. Defaults to 100ms, which is also
+// used for non-positive intervals.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval <= 0 {
+			interval = defaultOptions.flushInterval
+		}
+		o.flushInterval = interval
+	}
+}
+
+// WithQueueSize sets the number of metrics which can wait to be sent. Metrics reported while the queue is full
+// are dropped, so that calls are never blocked by the agent. Defaults to 4096, which is also used for negative
+// sizes.
+func WithQueueSize(size int) Option {
+	return func(o *options) {
+		if size < 0 {
+			size = defaultOptions.queueSize
+		}
+		o.queueSize = size
+	}
+}
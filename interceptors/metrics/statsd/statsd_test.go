@@ -0,0 +1,244 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package statsd_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/metrics/statsd"
+)
+
+const (
+	echoProcedure   = "/test.v1.TestService/Echo"
+	failProcedure   = "/test.v1.TestService/Fail"
+	streamProcedure = "/test.v1.TestService/Stream"
+)
+
+// listen returns the address of a local UDP listener, and a function reading the packets it received until
+// no packet is received for a while.
+func listen(t *testing.T) (string, func() []string) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn.LocalAddr().String(), func() []string {
+		var packets []string
+		buf := make([]byte, 65536)
+		for {
+			_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return packets
+			}
+			packets = append(packets, string(buf[:n]))
+		}
+	}
+}
+
+func setup(t *testing.T, m *statsd.Metrics) (echo, fail, stream *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue]) {
+	serverInterceptors := connect.WithInterceptors(m.UnaryServerInterceptor(), m.StreamServerInterceptor())
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		return connect.NewResponse(req.Msg), nil
+	}, serverInterceptors))
+	mux.Handle(failProcedure, connect.NewUnaryHandler(failProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		return nil, connect.NewError(connect.CodeUnavailable, errors.New("down"))
+	}, serverInterceptors))
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+		for i := 0; i < 2; i++ {
+			if err := stream.Send(wrapperspb.String(req.Msg.Value + req.Msg.Value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, serverInterceptors))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	clientInterceptors := connect.WithInterceptors(m.UnaryClientInterceptor(), m.StreamClientInterceptor())
+	echo = connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+echoProcedure, clientInterceptors)
+	fail = connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+failProcedure, clientInterceptors)
+	stream = connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+streamProcedure, clientInterceptors)
+	return echo, fail, stream
+}
+
+func lines(packets []string) []string {
+	var l []string
+	for _, p := range packets {
+		l = append(l, strings.Split(p, "\n")...)
+	}
+	return l
+}
+
+func TestMetrics(t *testing.T) {
+	addr, read := listen(t)
+	m, err := statsd.New(addr, statsd.WithTags("env:test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo, fail, stream := setup(t, m)
+	if _, err := echo.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fail.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping"))); err == nil {
+		t.Fatal("expected error")
+	}
+	res, err := stream.CallServerStream(context.Background(), connect.NewRequest(wrapperspb.String("ping")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for res.Receive() {
+	}
+	if err := res.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := lines(read())
+	tags := "env:test,connect_type:unary,connect_service:test.v1.TestService"
+	streamTags := "env:test,connect_type:server_stream,connect_service:test.v1.TestService,connect_method:Stream"
+	// Sizes are the encoded sizes of "ping" and "pingping".
+	for _, want := range []string{
+		"connect.server.started:1|c|#" + tags + ",connect_method:Echo",
+		"connect.client.started:1|c|#" + tags + ",connect_method:Echo",
+		"connect.server.handled:1|c|#" + tags + ",connect_method:Echo,connect_code:ok",
+		"connect.server.msg_received:1|c|#" + tags + ",connect_method:Echo",
+		"connect.server.msg_sent:1|c|#" + tags + ",connect_method:Echo",
+		"connect.server.request.size:6|d|#" + tags + ",connect_method:Echo",
+		"connect.server.response.size:6|d|#" + tags + ",connect_method:Echo",
+		"connect.client.request.size:6|d|#" + tags + ",connect_method:Echo",
+		"connect.client.response.size:6|d|#" + tags + ",connect_method:Echo",
+		"connect.server.handled:1|c|#" + tags + ",connect_method:Fail,connect_code:unavailable",
+		"connect.client.handled:1|c|#" + tags + ",connect_method:Fail,connect_code:unavailable",
+		// The request of failed calls was sent all the same.
+		"connect.client.msg_sent:1|c|#" + tags + ",connect_method:Fail",
+		"connect.client.request.size:6|d|#" + tags + ",connect_method:Fail",
+		"connect.server.request.size:6|d|#" + streamTags,
+		"connect.server.response.size:10|d|#" + streamTags,
+		"connect.client.request.size:6|d|#" + streamTags,
+		"connect.client.response.size:10|d|#" + streamTags,
+	} {
+		var found bool
+		for _, l := range got {
+			if l == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing metric %q in %q", want, got)
+		}
+	}
+
+	var timings int
+	for _, l := range got {
+		if strings.HasPrefix(l, "connect.server.handling_time:") && strings.Contains(l, "|ms|#") {
+			timings++
+		}
+	}
+	if timings != 3 {
+		t.Errorf("expected 3 server timings, got %d", timings)
+	}
+	var responseSizes int
+	for _, l := range got {
+		if strings.HasPrefix(l, "connect.client.response.size:10|d|#"+streamTags) {
+			responseSizes++
+		}
+	}
+	if responseSizes != 2 {
+		t.Errorf("expected the size of the 2 messages of the stream, got %d", responseSizes)
+	}
+}
+
+func TestMetrics_Batching(t *testing.T) {
+	addr, read := listen(t)
+	m, err := statsd.New(addr, statsd.WithMaxPacketSize(512), statsd.WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo, _, _ := setup(t, m)
+	for i := 0; i < 10; i++ {
+		if _, err := echo.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	packets := read()
+	if len(packets) < 2 {
+		t.Fatalf("expected metrics to be split in several packets, got %d", len(packets))
+	}
+	for _, p := range packets {
+		if len(p) > 512 {
+			t.Errorf("packet of %d bytes exceeds the maximum packet size", len(p))
+		}
+	}
+	// Every call reports 7 metrics on the server and on the client.
+	if got := len(lines(packets)); got != 140 {
+		t.Errorf("expected 140 metrics, got %d", got)
+	}
+}
+
+func TestMetrics_InvalidOptions(t *testing.T) {
+	addr, read := listen(t)
+	// Invalid values fall back to the defaults.
+	m, err := statsd.New(addr, statsd.WithQueueSize(-1), statsd.WithFlushInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo, _, _ := setup(t, m)
+	if _, err := echo.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping"))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Metrics are flushed without closing.
+	if got := len(lines(read())); got != 14 {
+		t.Errorf("expected 14 metrics, got %d", got)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetrics_SampleRate(t *testing.T) {
+	addr, read := listen(t)
+	m, err := statsd.New(addr, statsd.WithSampleRate(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo, _, _ := setup(t, m)
+	for i := 0; i < 20; i++ {
+		if _, err := echo.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := lines(read())
+	if len(got) == 0 || len(got) >= 280 {
+		t.Fatalf("expected about half of the 280 metrics to be sent, got %d", len(got))
+	}
+	for _, l := range got {
+		if !strings.Contains(l, "|@0.5|") {
+			t.Errorf("expected sample rate in %q", l)
+		}
+	}
+}
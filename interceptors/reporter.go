@@ -5,6 +5,8 @@ package interceptors
 
 import (
 	"context"
+	"errors"
+	"io"
 	"time"
 
 	"connectrpc.com/connect"
@@ -19,6 +21,32 @@ const (
 	BidiStream   ConnectType = "bidi_stream"
 )
 
+// ConnectTypeOf returns the ConnectType of the given connect.StreamType.
+func ConnectTypeOf(t connect.StreamType) ConnectType {
+	switch t {
+	case connect.StreamTypeClient:
+		return ClientStream
+	case connect.StreamTypeServer:
+		return ServerStream
+	case connect.StreamTypeBidi:
+		return BidiStream
+	default:
+		return Unary
+	}
+}
+
+// CodeOK is the string used for the code of successful calls, connect has no code for them.
+const CodeOK = "ok"
+
+// CodeString returns the connect code of the given error as a string, or CodeOK if there is no error.
+// io.EOF, which signals the normal end of a stream, is not considered an error.
+func CodeString(err error) string {
+	if err == nil || errors.Is(err, io.EOF) {
+		return CodeOK
+	}
+	return connect.CodeOf(err).String()
+}
+
 var (
 	AllCodes = []connect.Code{
 		connect.CodeCanceled,
@@ -0,0 +1,54 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package requestid
+
+var (
+	defaultOptions = &options{
+		header:    DefaultHeader,
+		fieldKey:  DefaultFieldKey,
+		generator: NewUUID,
+	}
+)
+
+type options struct {
+	header    string
+	fieldKey  string
+	generator Generator
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// Generator returns a new request ID.
+type Generator func() string
+
+// WithHeader customizes the header the request ID is read from, echoed in and forwarded in. Defaults to DefaultHeader.
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithFieldKey customizes the key of the logging field holding the request ID. Defaults to DefaultFieldKey.
+func WithFieldKey(key string) Option {
+	return func(o *options) {
+		o.fieldKey = key
+	}
+}
+
+// WithGenerator customizes how request IDs are generated when the request doesn't carry one, e.g. NewULID.
+// Defaults to NewUUID.
+func WithGenerator(g Generator) Option {
+	return func(o *options) {
+		o.generator = g
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package requestid
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+const (
+	// DefaultHeader is the default header carrying the request ID.
+	DefaultHeader = "X-Request-Id"
+	// DefaultFieldKey is the default key of the logging field holding the request ID.
+	DefaultFieldKey = "request_id"
+
+	// maxLength is the maximum length of a request ID accepted from incoming headers.
+	maxLength = 128
+)
+
+type idCtxMarker struct{}
+
+var (
+	// idCtxMarkerKey is the Context value marker that is used to read and write the request ID into context.
+	idCtxMarkerKey = &idCtxMarker{}
+)
+
+// FromContext returns the request ID stored in the context, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(idCtxMarkerKey).(string)
+	return id, ok
+}
+
+// NewContext returns a copy of the context holding the given request ID, which the client interceptor forwards
+// on outgoing calls.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idCtxMarkerKey, id)
+}
+
+// ServerInterceptor returns a new server interceptor reading the request ID from incoming headers, or generating
+// one if there is none. The ID is stored in the context (see FromContext), added to the logging fields and echoed
+// in the response headers.
+//
+// It has to be placed before the logging interceptor for the log lines of the call to carry the request ID.
+func ServerInterceptor(opts ...Option) connect.Interceptor {
+	return &serverInterceptor{opts: evaluateOptions(opts)}
+}
+
+// ClientInterceptor returns a new client interceptor forwarding the request ID found in the context, if any,
+// on outgoing calls.
+func ClientInterceptor(opts ...Option) connect.Interceptor {
+	return &clientInterceptor{opts: evaluateOptions(opts)}
+}
+
+type serverInterceptor struct {
+	opts *options
+}
+
+func (i *serverInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return connect.UnaryFunc(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		id := i.requestID(req.Header().Get(i.opts.header))
+		resp, err := next(i.newContext(ctx, id), req)
+		// On errors, resp is usually a typed nil and the ID is set on the error metadata instead.
+		if err == nil {
+			resp.Header().Set(i.opts.header, id)
+		}
+		if err != nil {
+			err = withHeader(err, i.opts.header, id)
+		}
+		return resp, err
+	})
+}
+
+// withHeader returns a copy of err with the given header set in its metadata. The error itself is left untouched,
+// as handlers may return the same error to concurrent calls.
+func withHeader(err error, key, value string) error {
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		// Connect would turn the error into a connect.CodeUnknown one anyway, without metadata.
+		connectErr = connect.NewError(connect.CodeUnknown, err)
+		connectErr.Meta().Set(key, value)
+		return connectErr
+	}
+	clone := connect.NewError(connectErr.Code(), &clonedError{err: err, msg: connectErr.Message()})
+	for _, detail := range connectErr.Details() {
+		clone.AddDetail(detail)
+	}
+	for k, v := range connectErr.Meta() {
+		clone.Meta()[k] = append([]string(nil), v...)
+	}
+	clone.Meta().Set(key, value)
+	return clone
+}
+
+// clonedError keeps the message of a cloned connect.Error, while errors.Is and errors.As still find the original.
+type clonedError struct {
+	err error
+	msg string
+}
+
+func (e *clonedError) Error() string {
+	return e.msg
+}
+
+func (e *clonedError) Unwrap() error {
+	return e.err
+}
+
+func (i *serverInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *serverInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return connect.StreamingHandlerFunc(func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		id := i.requestID(conn.RequestHeader().Get(i.opts.header))
+		conn.ResponseHeader().Set(i.opts.header, id)
+		return next(i.newContext(ctx, id), conn)
+	})
+}
+
+func (i *serverInterceptor) newContext(ctx context.Context, id string) context.Context {
+	return logging.InjectLogField(NewContext(ctx, id), i.opts.fieldKey, id)
+}
+
+// requestID returns the incoming request ID if valid, a new one otherwise.
+func (i *serverInterceptor) requestID(incoming string) string {
+	if incoming == "" || len(incoming) > maxLength {
+		return i.opts.generator()
+	}
+	for _, c := range incoming {
+		// Only printable ASCII is accepted, as the ID ends up in headers and logs.
+		if c < 0x21 || c > 0x7e {
+			return i.opts.generator()
+		}
+	}
+	return incoming
+}
+
+type clientInterceptor struct {
+	opts *options
+}
+
+func (i *clientInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return connect.UnaryFunc(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if id, ok := FromContext(ctx); ok && req.Spec().IsClient {
+			req.Header().Set(i.opts.header, id)
+		}
+		return next(ctx, req)
+	})
+}
+
+func (i *clientInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return connect.StreamingClientFunc(func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		if id, ok := FromContext(ctx); ok {
+			conn.RequestHeader().Set(i.opts.header, id)
+		}
+		return conn
+	})
+}
+
+func (i *clientInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
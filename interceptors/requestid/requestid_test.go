@@ -0,0 +1,113 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package requestid_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/requestid"
+)
+
+const procedure = "/test.v1.TestService/Echo"
+
+// newServer returns a server answering with the request ID found in the handler context, or failing with err.
+func newServer(t *testing.T, err error) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		if err != nil {
+			return nil, err
+		}
+		id, _ := requestid.FromContext(ctx)
+		return connect.NewResponse(wrapperspb.String(id)), nil
+	}, connect.WithInterceptors(requestid.ServerInterceptor(requestid.WithGenerator(func() string { return "generated" })))))
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func call(ctx context.Context, url string) (*connect.Response[wrapperspb.StringValue], error) {
+	c := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		http.DefaultClient, url+procedure,
+		connect.WithInterceptors(requestid.ClientInterceptor()),
+	)
+	return c.CallUnary(ctx, connect.NewRequest(wrapperspb.String("hi")))
+}
+
+func TestRequestIDForwardedAndEchoed(t *testing.T) {
+	s := newServer(t, nil)
+
+	resp, err := call(requestid.NewContext(context.Background(), "incoming-id"), s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Msg.Value != "incoming-id" || resp.Header().Get(requestid.DefaultHeader) != "incoming-id" {
+		t.Fatalf("got %q and header %q, want the forwarded ID", resp.Msg.Value, resp.Header().Get(requestid.DefaultHeader))
+	}
+
+	resp, err = call(context.Background(), s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Msg.Value != "generated" || resp.Header().Get(requestid.DefaultHeader) != "generated" {
+		t.Fatalf("got %q and header %q, want a generated ID", resp.Msg.Value, resp.Header().Get(requestid.DefaultHeader))
+	}
+}
+
+func TestRequestIDEchoedOnError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		code connect.Code
+	}{
+		{name: "connect error", err: connect.NewError(connect.CodeNotFound, errors.New("not found")), code: connect.CodeNotFound},
+		{name: "plain error", err: errors.New("boom"), code: connect.CodeUnknown},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newServer(t, tc.err)
+
+			_, err := call(requestid.NewContext(context.Background(), "incoming-id"), s.URL)
+			var connectErr *connect.Error
+			if !errors.As(err, &connectErr) || connectErr.Code() != tc.code {
+				t.Fatalf("err = %v, want code %v", err, tc.code)
+			}
+			if got := connectErr.Meta().Get(requestid.DefaultHeader); got != "incoming-id" {
+				t.Fatalf("error metadata holds ID %q, want the request ID", got)
+			}
+		})
+	}
+}
+
+var errNotFound = func() *connect.Error {
+	err := connect.NewError(connect.CodeNotFound, errors.New("not found"))
+	err.Meta().Set("X-Error", "meta")
+	return err
+}()
+
+func TestRequestIDSharedError(t *testing.T) {
+	s := newServer(t, errNotFound)
+
+	for _, id := range []string{"first-id", "second-id"} {
+		_, err := call(requestid.NewContext(context.Background(), id), s.URL)
+		var connectErr *connect.Error
+		if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeNotFound || connectErr.Message() != "not found" {
+			t.Fatalf("err = %v, want %v", err, errNotFound)
+		}
+		if got := connectErr.Meta().Values(requestid.DefaultHeader); len(got) != 1 || got[0] != id {
+			t.Fatalf("error metadata holds IDs %q, want %q", got, id)
+		}
+		if got := connectErr.Meta().Get("X-Error"); got != "meta" {
+			t.Fatalf("error metadata holds %q, want the handler metadata", got)
+		}
+	}
+	if got := errNotFound.Meta().Get(requestid.DefaultHeader); got != "" {
+		t.Fatalf("shared error holds ID %q, want it untouched", got)
+	}
+}
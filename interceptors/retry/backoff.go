@@ -0,0 +1,53 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"math/rand"
+	"time"
+)
+
+// BackoffFunc denotes a family of functions that control the backoff duration between call retries.
+//
+// They are called with an identifier of the attempt, and should return a time the system client should
+// hold off for. If the time returned is longer than the `context.Context.Deadline` of the request
+// the deadline of the request takes precedence and the wait will be interrupted before proceeding
+// with the next iteration.
+type BackoffFunc func(attempt uint) time.Duration
+
+// BackoffLinear is very simple: it waits for a fixed period of time between calls.
+func BackoffLinear(waitBetween time.Duration) BackoffFunc {
+	return func(attempt uint) time.Duration {
+		return waitBetween
+	}
+}
+
+// BackoffExponentialWithJitter creates an exponential backoff like BackoffExponential does, but adds jitter.
+// The jitter is a fraction of the computed duration, e.g. 0.10 gives +/- 10%.
+func BackoffExponentialWithJitter(scalar time.Duration, jitterFraction float64) BackoffFunc {
+	return func(attempt uint) time.Duration {
+		return jitterUp(exponentBase2(attempt)*scalar, jitterFraction)
+	}
+}
+
+// BackoffExponential produces increasing intervals for each attempt.
+// The scalar is multiplied times 2 raised to the current attempt. So the first
+// retry with a scalar of 100ms is 100ms, while the 5th attempt would be 1.6s.
+func BackoffExponential(scalar time.Duration) BackoffFunc {
+	return func(attempt uint) time.Duration {
+		return exponentBase2(attempt) * scalar
+	}
+}
+
+// exponentBase2 computes 2^(a-1) where a >= 1. If a is 0, the result is 0.
+func exponentBase2(a uint) time.Duration {
+	return (1 << a) >> 1
+}
+
+// jitterUp adds random jitter to the duration.
+// This adds or subtracts time from the duration within a given jitter fraction.
+func jitterUp(duration time.Duration, jitter float64) time.Duration {
+	multiplier := jitter * (rand.Float64()*2 - 1)
+	return time.Duration(float64(duration) * (1 + multiplier))
+}
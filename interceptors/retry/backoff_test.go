@@ -0,0 +1,57 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors/retry"
+)
+
+func TestBackoffLinear(t *testing.T) {
+	bf := retry.BackoffLinear(50 * time.Millisecond)
+	for attempt := uint(0); attempt < 5; attempt++ {
+		if got := bf(attempt); got != 50*time.Millisecond {
+			t.Fatalf("attempt %d: got %v, want 50ms", attempt, got)
+		}
+	}
+}
+
+func TestBackoffExponential(t *testing.T) {
+	bf := retry.BackoffExponential(100 * time.Millisecond)
+	for attempt, want := range []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, 1600 * time.Millisecond} {
+		if got := bf(uint(attempt)); got != want {
+			t.Fatalf("attempt %d: got %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestBackoffExponentialWithJitter(t *testing.T) {
+	bf := retry.BackoffExponentialWithJitter(100*time.Millisecond, 0.1)
+	for i := 0; i < 1000; i++ {
+		got := bf(3)
+		if got < 360*time.Millisecond || got > 440*time.Millisecond {
+			t.Fatalf("got %v, want 400ms +/- 10%%", got)
+		}
+	}
+	if got := retry.BackoffExponentialWithJitter(100*time.Millisecond, 0)(3); got != 400*time.Millisecond {
+		t.Fatalf("got %v, want no jitter", got)
+	}
+}
+
+func TestBackoffWaitedBetweenRetries(t *testing.T) {
+	var calls int32
+	s := newServer(t, connect.CodeUnavailable, &calls)
+	start := time.Now()
+	_ = call(t, s.URL, retry.WithMax(2), retry.WithBackoff(retry.BackoffLinear(30*time.Millisecond)))
+	if calls != 3 {
+		t.Fatalf("server got %d calls, want 3", calls)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("retries took %v, want at least two 30ms backoffs", elapsed)
+	}
+}
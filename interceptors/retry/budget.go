@@ -0,0 +1,147 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+var _ interceptors.ClientReportable = &Budget{}
+
+// Budget is a retry budget shared across a client, similar to the ones of Envoy or Finagle.
+//
+// It holds a pool of tokens. Every successful call deposits `ratio` tokens into the pool (up to `maxTokens`),
+// and every retry withdraws a whole token. Retries are denied when the pool is empty, which keeps the ratio of
+// retries to successful requests under `ratio` once the initial reserve is spent. During an outage, when no call
+// succeeds, the pool is not replenished and retries stop quickly instead of multiplying the load.
+//
+// Budget implements interceptors.ClientReportable: the outcome of every call attempt is fed to it through the
+// Reporter's PostCall. The retry interceptor does that on its own for every attempt it makes.
+//
+// Retries denied by an exhausted budget are counted in Stats. When the retry interceptor has a logger (see
+// WithLogger), each denial is also logged at warning level with the "retry.budget_exhausted" field, and the
+// interceptor returns the error of the last attempt.
+type Budget struct {
+	ratio     float64
+	maxTokens float64
+
+	mu     sync.Mutex
+	tokens float64
+	stats  BudgetStats
+}
+
+// BudgetStats is a snapshot of the budget counters, e.g. to be exported as metrics.
+type BudgetStats struct {
+	// Requests is the number of call attempts reported to the budget, retries included.
+	Requests uint64
+	// Successes is the number of call attempts that succeeded.
+	Successes uint64
+	// Retries is the number of retries allowed by the budget.
+	Retries uint64
+	// Exhausted is the number of retries denied because the budget was exhausted.
+	Exhausted uint64
+	// Tokens is the current balance of the pool.
+	Tokens float64
+}
+
+type BudgetOption func(*Budget)
+
+// WithBudgetRatio sets the number of tokens deposited by each successful call, e.g. 0.1 allows one retry
+// for every ten successful calls. Defaults to 0.1.
+func WithBudgetRatio(ratio float64) BudgetOption {
+	return func(b *Budget) {
+		b.ratio = ratio
+	}
+}
+
+// WithBudgetMaxTokens caps the pool so that a long period of successes can't be followed by an unbounded
+// burst of retries. Defaults to 100.
+func WithBudgetMaxTokens(n float64) BudgetOption {
+	return func(b *Budget) {
+		b.maxTokens = n
+	}
+}
+
+// WithBudgetInitialTokens sets the reserve a new budget starts with, so that a client can retry before it
+// made any successful call. Defaults to 10.
+func WithBudgetInitialTokens(n float64) BudgetOption {
+	return func(b *Budget) {
+		b.tokens = n
+	}
+}
+
+// NewBudget returns a new retry budget. See `Budget` for details.
+func NewBudget(opts ...BudgetOption) *Budget {
+	b := &Budget{
+		ratio:     0.1,
+		maxTokens: 100,
+		tokens:    10,
+	}
+	for _, o := range opts {
+		o(b)
+	}
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+	return b
+}
+
+// Stats returns a snapshot of the budget counters.
+func (b *Budget) Stats() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.stats
+	s.Tokens = b.tokens
+	return s
+}
+
+// ClientReporter implements interceptors.ClientReportable.
+func (b *Budget) ClientReporter(ctx context.Context, _ interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return &budgetReporter{budget: b}, ctx
+}
+
+// withdraw takes a token from the pool for a retry. It returns false if the budget is exhausted.
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		b.stats.Exhausted++
+		return false
+	}
+	b.tokens--
+	b.stats.Retries++
+	return true
+}
+
+func (b *Budget) report(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats.Requests++
+	if err != nil {
+		return
+	}
+	b.stats.Successes++
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+// budgetReporter only cares about call outcomes, messages are ignored.
+type budgetReporter struct {
+	interceptors.NoopReporter
+
+	budget *Budget
+}
+
+func (r *budgetReporter) PostCall(err error, _ time.Duration) {
+	r.budget.report(err)
+}
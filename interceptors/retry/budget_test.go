@@ -0,0 +1,160 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+	"github.com/svrana/go-connect-middleware/interceptors/retry"
+)
+
+const procedure = "/test.v1.TestService/Echo"
+
+// newServer returns a server failing with the given code (if not zero) and counting its calls.
+func newServer(t *testing.T, code connect.Code, calls *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		atomic.AddInt32(calls, 1)
+		if code != 0 {
+			return nil, connect.NewError(code, errors.New("failed"))
+		}
+		return connect.NewResponse(req.Msg), nil
+	}))
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func call(t *testing.T, url string, opts ...retry.Option) error {
+	c := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		http.DefaultClient, url+procedure,
+		connect.WithInterceptors(retry.UnaryClientInterceptor(opts...)),
+	)
+	_, err := c.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("hi")))
+	return err
+}
+
+type logLine struct {
+	msg    string
+	fields logging.Fields
+}
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []logLine
+}
+
+func (l *recordingLogger) Log(_ context.Context, _ logging.Level, msg string, fields ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, logLine{msg: msg, fields: append(logging.Fields(nil), fields...)})
+}
+
+func reportCall(b *retry.Budget, err error) {
+	r, _ := b.ClientReporter(context.Background(), interceptors.CallMeta{})
+	r.PostCall(err, 0)
+}
+
+func TestBudgetAccounting(t *testing.T) {
+	b := retry.NewBudget(retry.WithBudgetRatio(0.5), retry.WithBudgetMaxTokens(2), retry.WithBudgetInitialTokens(1))
+	if got := b.Stats(); got.Tokens != 1 {
+		t.Fatalf("initial tokens = %v, want 1", got.Tokens)
+	}
+
+	reportCall(b, nil)
+	reportCall(b, errors.New("failed"))
+	got := b.Stats()
+	want := retry.BudgetStats{Requests: 2, Successes: 1, Tokens: 1.5}
+	if got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+
+	for i := 0; i < 10; i++ {
+		reportCall(b, nil)
+	}
+	if got := b.Stats().Tokens; got != 2 {
+		t.Fatalf("tokens = %v, want them capped at 2", got)
+	}
+}
+
+func TestBudgetInitialTokensCapped(t *testing.T) {
+	b := retry.NewBudget(retry.WithBudgetMaxTokens(3), retry.WithBudgetInitialTokens(10))
+	if got := b.Stats().Tokens; got != 3 {
+		t.Fatalf("tokens = %v, want 3", got)
+	}
+}
+
+func TestBudgetLimitsRetries(t *testing.T) {
+	var calls int32
+	s := newServer(t, connect.CodeUnavailable, &calls)
+	b := retry.NewBudget(retry.WithBudgetInitialTokens(2))
+	logger := &recordingLogger{}
+
+	err := call(t, s.URL, retry.WithMax(5), retry.WithBackoff(retry.BackoffLinear(0)), retry.WithBudget(b), retry.WithLogger(logger))
+	if connect.CodeOf(err) != connect.CodeUnavailable {
+		t.Fatalf("err = %v, want the last attempt's error", err)
+	}
+	if calls != 3 {
+		t.Fatalf("server got %d calls, want 1 call and 2 retries", calls)
+	}
+	got := b.Stats()
+	want := retry.BudgetStats{Requests: 3, Retries: 2, Exhausted: 1}
+	if got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+
+	last := logger.lines[len(logger.lines)-1]
+	if last.msg != "retry budget exhausted" {
+		t.Fatalf("last log line = %q, want the exhaustion to be logged", last.msg)
+	}
+	var exhausted, attempt any
+	for i := logging.Fields(last.fields).Iterator(); i.Next(); {
+		k, v := i.At()
+		switch k {
+		case retry.BudgetExhaustedFieldKey:
+			exhausted = v
+		case retry.AttemptFieldKey:
+			attempt = v
+		}
+	}
+	if exhausted != true || attempt != uint(3) {
+		t.Fatalf("fields = %v, want %s=true and %s=3", last.fields, retry.BudgetExhaustedFieldKey, retry.AttemptFieldKey)
+	}
+}
+
+func TestBudgetReplenishedBySuccesses(t *testing.T) {
+	var okCalls, failCalls int32
+	ok := newServer(t, 0, &okCalls)
+	failing := newServer(t, connect.CodeUnavailable, &failCalls)
+	b := retry.NewBudget(retry.WithBudgetRatio(0.5), retry.WithBudgetInitialTokens(0))
+	opts := []retry.Option{retry.WithMax(3), retry.WithBackoff(retry.BackoffLinear(0)), retry.WithBudget(b)}
+
+	_ = call(t, failing.URL, opts...)
+	if failCalls != 1 {
+		t.Fatalf("failing server got %d calls, want no retry from an empty budget", failCalls)
+	}
+	for i := 0; i < 2; i++ {
+		if err := call(t, ok.URL, opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = call(t, failing.URL, opts...)
+	if failCalls != 3 {
+		t.Fatalf("failing server got %d calls, want one retry paid by two successes", failCalls-1)
+	}
+	if got := b.Stats(); got.Retries != 1 || got.Exhausted != 2 || got.Successes != 2 {
+		t.Fatalf("stats = %+v", got)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+var (
+	// DefaultRetriableCodes is a set of well known connect codes that should be retri-able.
+	//
+	// `ResourceExhausted` means that the user quota, e.g. per-RPC limits, have been reached.
+	// `Unavailable` means that system is currently unavailable and the client should retry again.
+	DefaultRetriableCodes = []connect.Code{connect.CodeResourceExhausted, connect.CodeUnavailable}
+
+	defaultOptions = &options{
+		max:     0, // disabled
+		codes:   DefaultRetriableCodes,
+		backoff: BackoffLinear(50 * time.Millisecond),
+	}
+)
+
+type options struct {
+	max     uint
+	codes   []connect.Code
+	backoff BackoffFunc
+	budget  *Budget
+	logger  logging.Logger
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithMax sets the maximum number of retries on this call, or this interceptor.
+// Zero disables retries.
+func WithMax(maxRetries uint) Option {
+	return func(o *options) {
+		o.max = maxRetries
+	}
+}
+
+// WithCodes sets which codes should be retried.
+//
+// Please *use with care*, as you may be retrying non-idempotent calls.
+func WithCodes(retryCodes ...connect.Code) Option {
+	return func(o *options) {
+		o.codes = retryCodes
+	}
+}
+
+// WithBackoff sets the `BackoffFunc` used to control time between retries.
+func WithBackoff(bf BackoffFunc) Option {
+	return func(o *options) {
+		o.backoff = bf
+	}
+}
+
+// WithBudget limits retries with the given retry budget. The same budget is meant to be shared by
+// every interceptor of a client (or of several clients), so that retries can't multiply load during an outage.
+// See `NewBudget` for details.
+func WithBudget(b *Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithLogger logs retries and retry budget exhaustion to the given logger. Log lines carry the logging.Fields
+// found in the call context, plus the "retry.attempt" and, when the budget denied the retry, the
+// "retry.budget_exhausted" field.
+func WithLogger(logger logging.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+const (
+	// AttemptFieldKey is the log field holding the retry attempt number.
+	AttemptFieldKey = "retry.attempt"
+	// BudgetExhaustedFieldKey is the log field set when a retry was denied by the retry budget.
+	BudgetExhaustedFieldKey = "retry.budget_exhausted"
+)
+
+// UnaryClientInterceptor returns a new retrying unary client interceptor.
+//
+// The default configuration of the interceptor is to not retry *at all*. This behaviour can be
+// changed through options (e.g. WithMax). When a retry budget is configured with WithBudget, every
+// attempt is reported to it and retries are only made while the budget allows them.
+func UnaryClientInterceptor(opts ...Option) connect.UnaryInterceptorFunc {
+	o := evaluateOptions(opts)
+	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
+		return connect.UnaryFunc(func(
+			ctx context.Context,
+			req connect.AnyRequest,
+		) (connect.AnyResponse, error) {
+			if !req.Spec().IsClient || o.max == 0 {
+				return next(ctx, req)
+			}
+			callMeta := interceptors.NewClientCallMeta(req.Spec(), req)
+
+			var lastErr error
+			for attempt := uint(0); attempt <= o.max; attempt++ {
+				if attempt > 0 {
+					if o.budget != nil && !o.budget.withdraw() {
+						o.log(ctx, logging.LevelWarn, "retry budget exhausted", attempt, lastErr, BudgetExhaustedFieldKey, true)
+						return nil, lastErr
+					}
+					if err := waitRetryBackoff(ctx, attempt, o); err != nil {
+						return nil, err
+					}
+					o.log(ctx, logging.LevelDebug, "retrying call", attempt, lastErr)
+				}
+
+				start := time.Now()
+				resp, err := next(ctx, req)
+				if o.budget != nil {
+					reporter, _ := o.budget.ClientReporter(ctx, callMeta)
+					reporter.PostCall(err, time.Since(start))
+				}
+				if err == nil || !isRetriable(err, o) {
+					return resp, err
+				}
+				lastErr = err
+			}
+			return nil, lastErr
+		})
+	}
+	return connect.UnaryInterceptorFunc(interceptor)
+}
+
+func waitRetryBackoff(ctx context.Context, attempt uint, o *options) error {
+	waitTime := o.backoff(attempt)
+	if waitTime <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(waitTime)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return contextErrToConnectErr(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isRetriable(err error, o *options) bool {
+	code := connect.CodeOf(err)
+	if isContextError(code) {
+		// Don't retry if the context was canceled or deadline exceeded, the caller won't wait for it anyway.
+		return false
+	}
+	for _, c := range o.codes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+func isContextError(code connect.Code) bool {
+	return code == connect.CodeDeadlineExceeded || code == connect.CodeCanceled
+}
+
+func contextErrToConnectErr(err error) error {
+	if err == context.DeadlineExceeded {
+		return connect.NewError(connect.CodeDeadlineExceeded, err)
+	}
+	return connect.NewError(connect.CodeCanceled, err)
+}
+
+func (o *options) log(ctx context.Context, level logging.Level, msg string, attempt uint, err error, fields ...any) {
+	if o.logger == nil {
+		return
+	}
+	f := logging.ExtractFields(ctx).AppendUnique(logging.Fields(fields))
+	f = f.AppendUnique(logging.Fields{AttemptFieldKey, attempt})
+	if err != nil {
+		f = f.AppendUnique(logging.Fields{"error", err.Error()})
+	}
+	o.logger.Log(ctx, level, msg, f...)
+}
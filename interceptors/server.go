@@ -7,6 +7,8 @@ package interceptors
 
 import (
 	"context"
+	"errors"
+	"io"
 	"time"
 
 	"connectrpc.com/connect"
@@ -20,9 +22,15 @@ func UnaryServerInterceptor(reportable ServerReportable) connect.UnaryIntercepto
 		) (connect.AnyResponse, error) {
 			r := newReport(NewServerCallMeta(req.Spec(), req))
 			reporter, newCtx := reportable.ServerReporter(ctx, r.callMeta)
+			headers := &unaryHeaders{req: req}
+			setHeaders(reporter, headers)
 
 			reporter.PostMsgReceive(req, nil, time.Since(r.startTime))
 			resp, err := next(newCtx, req)
+			if err == nil {
+				// Failed calls return a typed nil response.
+				headers.resp = resp
+			}
 			reporter.PostMsgSend(resp, err, time.Since(r.startTime))
 
 			reporter.PostCall(err, time.Since(r.startTime))
@@ -31,3 +39,61 @@ func UnaryServerInterceptor(reportable ServerReportable) connect.UnaryIntercepto
 	}
 	return connect.UnaryInterceptorFunc(interceptor)
 }
+
+// StreamServerInterceptor returns a new streaming server interceptor reporting to the given reportable.
+// Unary calls pass through, use UnaryServerInterceptor for them.
+//
+// Streamed messages are reported to PostMsgReceive as a connect.AnyRequest, and to PostMsgSend as a
+// connect.AnyResponse, whose Any method returns the message and whose headers are empty. The end of the client
+// stream (io.EOF) is not reported as a received message.
+func StreamServerInterceptor(reportable ServerReportable) connect.Interceptor {
+	return &streamServerInterceptor{reportable: reportable}
+}
+
+type streamServerInterceptor struct {
+	reportable ServerReportable
+}
+
+func (i *streamServerInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return next
+}
+
+func (i *streamServerInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *streamServerInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return connect.StreamingHandlerFunc(func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		r := newReport(NewServerCallMeta(conn.Spec(), nil))
+		reporter, newCtx := i.reportable.ServerReporter(ctx, r.callMeta)
+		setHeaders(reporter, conn)
+
+		err := next(newCtx, &monitoredServerStream{StreamingHandlerConn: conn, reporter: reporter})
+		reporter.PostCall(err, time.Since(r.startTime))
+		return err
+	})
+}
+
+// monitoredServerStream wraps connect.StreamingHandlerConn allowing each Send/Receive call to be monitored.
+type monitoredServerStream struct {
+	connect.StreamingHandlerConn
+
+	reporter Reporter
+}
+
+func (s *monitoredServerStream) Send(m any) error {
+	start := time.Now()
+	err := s.StreamingHandlerConn.Send(m)
+	s.reporter.PostMsgSend(&messageResponse{msg: m}, err, time.Since(start))
+	return err
+}
+
+func (s *monitoredServerStream) Receive(m any) error {
+	start := time.Now()
+	err := s.StreamingHandlerConn.Receive(m)
+	if errors.Is(err, io.EOF) {
+		return err
+	}
+	s.reporter.PostMsgReceive(&messageRequest{msg: m}, err, time.Since(start))
+	return err
+}
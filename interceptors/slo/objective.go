@@ -0,0 +1,101 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// DefaultGoodCodes are the codes counted as good when an Objective doesn't set GoodCodes, in addition to
+// successful calls: codes caused by the client rather than the server.
+var DefaultGoodCodes = []connect.Code{
+	connect.CodeCanceled,
+	connect.CodeInvalidArgument,
+	connect.CodeNotFound,
+	connect.CodeAlreadyExists,
+	connect.CodePermissionDenied,
+	connect.CodeFailedPrecondition,
+	connect.CodeOutOfRange,
+	connect.CodeUnauthenticated,
+}
+
+// Objective is a service level objective over the calls of the procedures matching Pattern.
+type Objective struct {
+	// Name identifies the objective, e.g. "ping-availability".
+	Name string
+	// Pattern is matched against the full method of calls, using path.Match syntax,
+	// e.g. "/ping.v1.PingService/*".
+	Pattern string
+	// Target is the ratio of good calls to achieve, e.g. 0.999.
+	Target float64
+	// LatencyThreshold, if set, makes calls slower than the threshold bad, whatever their code.
+	LatencyThreshold time.Duration
+	// GoodCodes are the error codes counted as good. Defaults to DefaultGoodCodes.
+	GoodCodes []connect.Code
+}
+
+func (o Objective) validate() error {
+	if o.Name == "" {
+		return errors.New("slo: objective without name")
+	}
+	if _, err := path.Match(o.Pattern, ""); err != nil {
+		return fmt.Errorf("slo: objective %q: invalid pattern %q: %w", o.Name, o.Pattern, err)
+	}
+	if o.Target <= 0 || o.Target >= 1 {
+		return fmt.Errorf("slo: objective %q: target %v not in ]0, 1[", o.Name, o.Target)
+	}
+	return nil
+}
+
+// Alert fires when the burn rate of an objective's error budget exceeds BurnRate over both LongWindow and
+// ShortWindow. The short window makes the alert resolve quickly once the errors stop.
+//
+// A burn rate of 1 consumes the whole error budget in the SLO period, e.g. a burn rate of 14.4 over an hour
+// consumes 2% of a 30 days budget.
+type Alert struct {
+	// Name identifies the alert, e.g. "page".
+	Name        string
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	BurnRate    float64
+	// MinRequests, if set, is the number of calls each window must hold for the alert to fire, so that a few
+	// failed calls of a rarely called procedure don't page. A firing alert resolves when the traffic drops below it.
+	MinRequests uint64
+}
+
+// DefaultAlerts are the multi-window, multi-burn-rate alerts recommended for a 30 days SLO period by the
+// Google SRE workbook: pages for 2% of the budget consumed in an hour or 5% in 6 hours, tickets for 10% in
+// 3 days.
+var DefaultAlerts = []Alert{
+	{Name: "page", LongWindow: time.Hour, ShortWindow: 5 * time.Minute, BurnRate: 14.4},
+	{Name: "page", LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, BurnRate: 6},
+	{Name: "ticket", LongWindow: 3 * 24 * time.Hour, ShortWindow: 6 * time.Hour, BurnRate: 1},
+}
+
+func (a Alert) validate() error {
+	if a.ShortWindow < bucketWidth || a.LongWindow < a.ShortWindow {
+		return fmt.Errorf("slo: alert %q: windows must be at least %v, the short window no longer than the long one", a.Name, bucketWidth)
+	}
+	if a.BurnRate <= 0 {
+		return fmt.Errorf("slo: alert %q: burn rate must be positive", a.Name)
+	}
+	return nil
+}
+
+// AlertEvent is passed to the hook set with WithAlertHook when an alert starts or stops firing.
+type AlertEvent struct {
+	Time      time.Time
+	Objective string
+	Alert     Alert
+	// Firing is true when the alert starts firing, false when it resolves.
+	Firing bool
+	// LongBurnRate and ShortBurnRate are the burn rates over the long and short windows of the alert.
+	LongBurnRate  float64
+	ShortBurnRate float64
+}
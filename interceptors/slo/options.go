@@ -0,0 +1,34 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package slo
+
+type options struct {
+	alerts    []Alert
+	alertHook func(AlertEvent)
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{alerts: DefaultAlerts}
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithAlerts sets the alerts evaluated for every objective. Defaults to DefaultAlerts.
+func WithAlerts(alerts ...Alert) Option {
+	return func(o *options) {
+		o.alerts = alerts
+	}
+}
+
+// WithAlertHook sets a function called when an alert starts or stops firing, e.g. to notify on-call.
+// It is called synchronously at the end of a call, so it should not block.
+func WithAlertHook(f func(AlertEvent)) Option {
+	return func(o *options) {
+		o.alertHook = f
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+// bucketWidth is the resolution of the windows burn rates are computed over.
+const bucketWidth = time.Minute
+
+var _ interceptors.ServerReportable = &Tracker{}
+
+// Tracker evaluates service level objectives over the calls handled by its interceptors. It computes the burn
+// rate of every objective's error budget over the windows of the alerts, and calls the alert hook when an alert
+// starts or stops firing. Alerts are evaluated at most once a minute, on calls and on Status.
+//
+// Tracker is an http.Handler serving the status of the objectives as JSON, meant to be mounted on an admin mux.
+type Tracker struct {
+	opts       *options
+	objectives []*objectiveState
+	windows    []time.Duration
+
+	// matches caches the objectives matching a full method.
+	matches sync.Map
+}
+
+// New returns a new Tracker evaluating the given objectives. It returns an error if an objective or alert is
+// invalid.
+func New(objectives []Objective, opts ...Option) (*Tracker, error) {
+	o := evaluateOptions(opts)
+
+	var maxWindow time.Duration
+	windows := map[time.Duration]struct{}{}
+	for _, a := range o.alerts {
+		if err := a.validate(); err != nil {
+			return nil, err
+		}
+		windows[a.LongWindow] = struct{}{}
+		windows[a.ShortWindow] = struct{}{}
+		if a.LongWindow > maxWindow {
+			maxWindow = a.LongWindow
+		}
+	}
+
+	t := &Tracker{opts: o}
+	for w := range windows {
+		t.windows = append(t.windows, w)
+	}
+	sort.Slice(t.windows, func(i, j int) bool { return t.windows[i] < t.windows[j] })
+
+	buckets := int64(maxWindow / bucketWidth)
+	if buckets < 1 {
+		buckets = 1
+	}
+	for _, obj := range objectives {
+		if err := obj.validate(); err != nil {
+			return nil, err
+		}
+		t.objectives = append(t.objectives, newObjectiveState(obj, buckets, len(o.alerts)))
+	}
+	return t, nil
+}
+
+// Status is the current status of an objective.
+type Status struct {
+	Objective string  `json:"objective"`
+	Pattern   string  `json:"pattern"`
+	Target    float64 `json:"target"`
+	// Windows are the calls and burn rates over every window of the alerts, shortest first.
+	Windows []WindowStatus `json:"windows"`
+	Alerts  []AlertStatus  `json:"alerts"`
+}
+
+// WindowStatus holds the calls and burn rate of an objective over a window.
+type WindowStatus struct {
+	Window   time.Duration `json:"window"`
+	Total    uint64        `json:"total"`
+	Good     uint64        `json:"good"`
+	BurnRate float64       `json:"burn_rate"`
+}
+
+// AlertStatus holds the state of an alert of an objective.
+type AlertStatus struct {
+	Alert         Alert   `json:"alert"`
+	Firing        bool    `json:"firing"`
+	LongBurnRate  float64 `json:"long_burn_rate"`
+	ShortBurnRate float64 `json:"short_burn_rate"`
+}
+
+// Status evaluates the objectives and returns their status, in the order they were given to New.
+func (t *Tracker) Status() []Status {
+	now := time.Now()
+	statuses := make([]Status, 0, len(t.objectives))
+	for _, s := range t.objectives {
+		s.mu.Lock()
+		events := s.evaluate(now, t.opts.alerts)
+		b := s.advance(now)
+		status := Status{
+			Objective: s.objective.Name,
+			Pattern:   s.objective.Pattern,
+			Target:    s.objective.Target,
+			Windows:   make([]WindowStatus, 0, len(t.windows)),
+			Alerts:    make([]AlertStatus, 0, len(t.opts.alerts)),
+		}
+		for _, w := range t.windows {
+			total, good := s.window(b, w)
+			status.Windows = append(status.Windows, WindowStatus{
+				Window: w, Total: total, Good: good, BurnRate: s.burnRate(total, good),
+			})
+		}
+		for i, a := range t.opts.alerts {
+			status.Alerts = append(status.Alerts, AlertStatus{
+				Alert:         a,
+				Firing:        s.firing[i],
+				LongBurnRate:  s.burnRate(s.window(b, a.LongWindow)),
+				ShortBurnRate: s.burnRate(s.window(b, a.ShortWindow)),
+			})
+		}
+		s.mu.Unlock()
+
+		t.fire(events)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// ServeHTTP serves the status of the objectives as JSON.
+func (t *Tracker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(t.Status())
+}
+
+func (t *Tracker) fire(events []AlertEvent) {
+	if t.opts.alertHook == nil {
+		return
+	}
+	for _, e := range events {
+		t.opts.alertHook(e)
+	}
+}
+
+// ServerReporter implements interceptors.ServerReportable.
+func (t *Tracker) ServerReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	states := t.match(meta.FullMethod())
+	if len(states) == 0 {
+		return interceptors.NoopReporter{}, ctx
+	}
+	return &reporter{tracker: t, states: states}, ctx
+}
+
+func (t *Tracker) match(fullMethod string) []*objectiveState {
+	if m, ok := t.matches.Load(fullMethod); ok {
+		return m.([]*objectiveState)
+	}
+	var states []*objectiveState
+	for _, s := range t.objectives {
+		if ok, _ := path.Match(s.objective.Pattern, fullMethod); ok {
+			states = append(states, s)
+		}
+	}
+	t.matches.Store(fullMethod, states)
+	return states
+}
+
+// UnaryServerInterceptor is a connect server-side interceptor that tracks the objectives of Unary RPCs.
+func (t *Tracker) UnaryServerInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryServerInterceptor(t)
+}
+
+// StreamServerInterceptor is a connect server-side interceptor that tracks the objectives of Streaming RPCs.
+func (t *Tracker) StreamServerInterceptor() connect.Interceptor {
+	return interceptors.StreamServerInterceptor(t)
+}
+
+type reporter struct {
+	interceptors.NoopReporter
+
+	tracker *Tracker
+	states  []*objectiveState
+}
+
+func (r *reporter) PostCall(err error, rpcDuration time.Duration) {
+	now := time.Now()
+	for _, s := range r.states {
+		r.tracker.fire(s.record(now, s.isGood(err, rpcDuration), r.tracker.opts.alerts))
+	}
+}
+
+// objectiveState counts the good and total calls of an objective in a ring of one minute buckets.
+type objectiveState struct {
+	objective Objective
+	goodCodes map[connect.Code]struct{}
+
+	mu          sync.Mutex
+	good, total []uint64
+	// last is the index, since the epoch, of the most recent bucket, and evaluated the index of the bucket
+	// the alerts were last evaluated in.
+	last, evaluated int64
+	firing          []bool
+}
+
+func newObjectiveState(obj Objective, buckets int64, alerts int) *objectiveState {
+	goodCodes := obj.GoodCodes
+	if goodCodes == nil {
+		goodCodes = DefaultGoodCodes
+	}
+	s := &objectiveState{
+		objective: obj,
+		goodCodes: make(map[connect.Code]struct{}, len(goodCodes)),
+		good:      make([]uint64, buckets),
+		total:     make([]uint64, buckets),
+		last:      bucketOf(time.Now()),
+		firing:    make([]bool, alerts),
+	}
+	for _, c := range goodCodes {
+		s.goodCodes[c] = struct{}{}
+	}
+	return s
+}
+
+func bucketOf(t time.Time) int64 {
+	return t.UnixNano() / int64(bucketWidth)
+}
+
+func (s *objectiveState) isGood(err error, rpcDuration time.Duration) bool {
+	if s.objective.LatencyThreshold > 0 && rpcDuration > s.objective.LatencyThreshold {
+		return false
+	}
+	if interceptors.CodeString(err) == interceptors.CodeOK {
+		return true
+	}
+	_, ok := s.goodCodes[connect.CodeOf(err)]
+	return ok
+}
+
+// record counts a call and evaluates the alerts if not done yet in the current bucket, returning the alerts
+// which started or stopped firing.
+func (s *objectiveState) record(now time.Time, good bool, alerts []Alert) []AlertEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.advance(now)
+	n := int64(len(s.total))
+	s.total[b%n]++
+	if good {
+		s.good[b%n]++
+	}
+	return s.evaluate(now, alerts)
+}
+
+// advance clears the buckets between the most recent one and the one of t, which becomes the most recent.
+func (s *objectiveState) advance(t time.Time) int64 {
+	b := bucketOf(t)
+	n := int64(len(s.total))
+	for i := s.last + 1; i <= b && i <= s.last+n; i++ {
+		s.total[i%n], s.good[i%n] = 0, 0
+	}
+	if b > s.last {
+		s.last = b
+	}
+	return b
+}
+
+// window returns the total and good calls over the window ending with bucket b.
+func (s *objectiveState) window(b int64, window time.Duration) (total, good uint64) {
+	n := int64(len(s.total))
+	for i := b - int64(window/bucketWidth) + 1; i <= b; i++ {
+		total += s.total[i%n]
+		good += s.good[i%n]
+	}
+	return total, good
+}
+
+// burnRate returns the ratio of bad calls to the ratio allowed by the objective.
+func (s *objectiveState) burnRate(total, good uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(total-good) / float64(total) / (1 - s.objective.Target)
+}
+
+// evaluate updates the firing state of the alerts, at most once per bucket, and returns the alerts which
+// started or stopped firing.
+func (s *objectiveState) evaluate(now time.Time, alerts []Alert) []AlertEvent {
+	b := s.advance(now)
+	if b <= s.evaluated {
+		return nil
+	}
+	s.evaluated = b
+
+	var events []AlertEvent
+	for i, a := range alerts {
+		longTotal, longGood := s.window(b, a.LongWindow)
+		shortTotal, shortGood := s.window(b, a.ShortWindow)
+		long, short := s.burnRate(longTotal, longGood), s.burnRate(shortTotal, shortGood)
+		firing := long >= a.BurnRate && short >= a.BurnRate && longTotal >= a.MinRequests && shortTotal >= a.MinRequests
+		if firing != s.firing[i] {
+			s.firing[i] = firing
+			events = append(events, AlertEvent{
+				Time:          now,
+				Objective:     s.objective.Name,
+				Alert:         a,
+				Firing:        firing,
+				LongBurnRate:  long,
+				ShortBurnRate: short,
+			})
+		}
+	}
+	return events
+}
@@ -0,0 +1,86 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package slo
+
+import (
+	"testing"
+	"time"
+)
+
+// recordAt records calls at the given minute after start, and returns the alert events.
+func recordAt(s *objectiveState, start time.Time, minute int, good, bad int, alerts []Alert) []AlertEvent {
+	now := start.Add(time.Duration(minute) * bucketWidth)
+	var events []AlertEvent
+	for i := 0; i < good+bad; i++ {
+		events = append(events, s.record(now, i < good, alerts)...)
+	}
+	return events
+}
+
+func newTestState(t *testing.T, alerts []Alert) (*objectiveState, time.Time) {
+	for _, a := range alerts {
+		if err := a.validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	obj := Objective{Name: "availability", Pattern: "*", Target: 0.9}
+	s := newObjectiveState(obj, 60, len(alerts))
+	// Calls are recorded from the next bucket, so that the first one is evaluated.
+	return s, time.Unix(0, (s.last+1)*int64(bucketWidth))
+}
+
+func TestAlertFiresAndResolves(t *testing.T) {
+	alerts := []Alert{{Name: "page", LongWindow: 10 * time.Minute, ShortWindow: 2 * time.Minute, BurnRate: 2}}
+	s, start := newTestState(t, alerts)
+
+	events := recordAt(s, start, 0, 0, 1, alerts)
+	if len(events) != 1 || !events[0].Firing || events[0].Objective != "availability" || events[0].LongBurnRate < 9.99 {
+		t.Fatalf("events = %+v, want the alert to fire on the first failed call", events)
+	}
+	if events := recordAt(s, start, 0, 0, 10, alerts); len(events) != 0 {
+		t.Fatalf("events = %+v, want alerts evaluated once per minute", events)
+	}
+
+	// 100 good calls bring the burn rate of the short window under the threshold.
+	_ = recordAt(s, start, 1, 100, 0, alerts)
+	events = recordAt(s, start, 2, 1, 0, alerts)
+	if len(events) != 1 || events[0].Firing {
+		t.Fatalf("events = %+v, want the alert to resolve", events)
+	}
+}
+
+func TestAlertMinRequests(t *testing.T) {
+	alerts := []Alert{{Name: "page", LongWindow: 10 * time.Minute, ShortWindow: 2 * time.Minute, BurnRate: 2, MinRequests: 10}}
+	s, start := newTestState(t, alerts)
+
+	if events := recordAt(s, start, 0, 0, 5, alerts); len(events) != 0 {
+		t.Fatalf("events = %+v, want no alert below the minimum number of calls", events)
+	}
+	// The short window holds the 5 calls of the previous minute and the first one of this minute.
+	if events := recordAt(s, start, 1, 0, 20, alerts); len(events) != 0 {
+		t.Fatalf("events = %+v, want no alert below the minimum number of calls", events)
+	}
+	events := recordAt(s, start, 2, 0, 1, alerts)
+	if len(events) != 1 || !events[0].Firing {
+		t.Fatalf("events = %+v, want the alert to fire once the windows hold enough calls", events)
+	}
+
+	// The traffic stops: the alert resolves although the few calls left in the short window failed.
+	events = recordAt(s, start, 5, 0, 1, alerts)
+	if len(events) != 1 || events[0].Firing {
+		t.Fatalf("events = %+v, want the alert to resolve when the traffic drops", events)
+	}
+}
+
+func TestAlertValidation(t *testing.T) {
+	for _, a := range []Alert{
+		{Name: "short window", LongWindow: time.Hour, ShortWindow: time.Second, BurnRate: 1},
+		{Name: "long window", LongWindow: time.Minute, ShortWindow: time.Hour, BurnRate: 1},
+		{Name: "burn rate", LongWindow: time.Hour, ShortWindow: time.Minute},
+	} {
+		if err := a.validate(); err == nil {
+			t.Errorf("alert %q: want an error", a.Name)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceContext returns the hex encoded trace and span IDs of the OpenTelemetry span found in the context, and
+// whether it is sampled. The trace ID is empty if there is no valid span. It is meant to be used with
+// logging.WithTraceFields, to correlate log lines with traces.
+func TraceContext(ctx context.Context) (traceID, spanID string, sampled bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", "", false
+	}
+	return sc.TraceID().String(), sc.SpanID().String(), sc.IsSampled()
+}
@@ -0,0 +1,113 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package tracing
+
+import (
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	defaultOptions = &options{
+		propagator:    propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		messageEvents: true,
+		// tracerProvider is resolved when the interceptor is created, so that the global provider can be set first.
+		tracerProvider: nil,
+		// codeToStatus depends if it's client or server.
+		codeToStatus: nil,
+	}
+)
+
+type options struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+	codeToStatus   CodeToStatus
+	messageEvents  bool
+}
+
+type Option func(*options)
+
+func evaluateServerOpt(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	optCopy.codeToStatus = DefaultServerCodeToStatus
+	for _, o := range opts {
+		o(optCopy)
+	}
+	if optCopy.tracerProvider == nil {
+		optCopy.tracerProvider = otel.GetTracerProvider()
+	}
+	return optCopy
+}
+
+func evaluateClientOpt(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	optCopy.codeToStatus = DefaultClientCodeToStatus
+	for _, o := range opts {
+		o(optCopy)
+	}
+	if optCopy.tracerProvider == nil {
+		optCopy.tracerProvider = otel.GetTracerProvider()
+	}
+	return optCopy
+}
+
+// CodeToStatus function defines the mapping between connect return codes and span status codes.
+type CodeToStatus func(code connect.Code) codes.Code
+
+// DefaultServerCodeToStatus is the helper mapper that maps connect return codes to span status codes for
+// server side. Only codes signaling a server failure mark the span as failed, errors caused by the client
+// (e.g. InvalidArgument, NotFound) leave the status unset.
+func DefaultServerCodeToStatus(code connect.Code) codes.Code {
+	switch code {
+	case connect.CodeUnknown, connect.CodeDeadlineExceeded, connect.CodeUnimplemented, connect.CodeInternal,
+		connect.CodeUnavailable, connect.CodeDataLoss:
+		return codes.Error
+	default:
+		return codes.Unset
+	}
+}
+
+// DefaultClientCodeToStatus is the helper mapper that maps connect return codes to span status codes for
+// client side. Every error marks the span as failed.
+func DefaultClientCodeToStatus(code connect.Code) codes.Code {
+	return codes.Error
+}
+
+// WithTracerProvider sets the tracer provider spans are started with. Defaults to the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithPropagators sets the propagator extracting (server) and injecting (client) the trace context from and
+// into headers. Defaults to W3C Trace Context and Baggage. B3 headers can be supported by adding the B3
+// propagator of go.opentelemetry.io/contrib/propagators/b3, e.g.:
+//
+//	tracing.WithPropagators(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, b3.New()))
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		o.propagator = p
+	}
+}
+
+// WithCodeToStatus customizes the function for mapping connect return codes to span status codes.
+func WithCodeToStatus(f CodeToStatus) Option {
+	return func(o *options) {
+		o.codeToStatus = f
+	}
+}
+
+// WithMessageEvents sets whether span events are added for every message sent and received on streams.
+// Defaults to true.
+func WithMessageEvents(enabled bool) Option {
+	return func(o *options) {
+		o.messageEvents = enabled
+	}
+}
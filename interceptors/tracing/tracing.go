@@ -0,0 +1,240 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+)
+
+// ScopeName is the instrumentation scope name of the tracer used by the interceptors.
+const ScopeName = "github.com/svrana/go-connect-middleware/interceptors/tracing"
+
+// ServerInterceptor returns a new server interceptor starting a span for every unary and streaming call. The
+// trace context is extracted from the request headers, so the span continues the trace of the client.
+func ServerInterceptor(opts ...Option) connect.Interceptor {
+	o := evaluateServerOpt(opts)
+	return &serverInterceptor{opts: o, tracer: o.tracerProvider.Tracer(ScopeName)}
+}
+
+// ClientInterceptor returns a new client interceptor starting a span for every unary and streaming call, and
+// injecting its context into the request headers.
+func ClientInterceptor(opts ...Option) connect.Interceptor {
+	o := evaluateClientOpt(opts)
+	return &clientInterceptor{opts: o, tracer: o.tracerProvider.Tracer(ScopeName)}
+}
+
+// spanStartOptions returns the name and start options of the span of a call. Spans are named after the full
+// method, without leading slash as per the OpenTelemetry semantic conventions, e.g. "ping.v1.PingService/Ping".
+func spanStartOptions(spec connect.Spec, kind trace.SpanKind) (string, []trace.SpanStartOption) {
+	c := interceptors.NewServerCallMeta(spec, nil)
+	return strings.TrimPrefix(c.FullMethod(), "/"), []trace.SpanStartOption{
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			semconv.RPCSystemConnectRPC,
+			semconv.RPCService(c.Service),
+			semconv.RPCMethod(c.Method),
+		),
+	}
+}
+
+func finishSpan(span trace.Span, err error, codeToStatus CodeToStatus) {
+	if err != nil && !errors.Is(err, io.EOF) {
+		code := connect.CodeOf(err)
+		span.SetAttributes(semconv.RPCConnectRPCErrorCodeKey.String(code.String()))
+		if codeToStatus(code) == codes.Error {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+type serverInterceptor struct {
+	opts   *options
+	tracer trace.Tracer
+}
+
+func (i *serverInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return connect.UnaryFunc(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		ctx = i.opts.propagator.Extract(ctx, propagation.HeaderCarrier(req.Header()))
+		name, startOpts := spanStartOptions(req.Spec(), trace.SpanKindServer)
+		ctx, span := i.tracer.Start(ctx, name, startOpts...)
+
+		resp, err := next(ctx, req)
+		finishSpan(span, err, i.opts.codeToStatus)
+		return resp, err
+	})
+}
+
+func (i *serverInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *serverInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return connect.StreamingHandlerFunc(func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx = i.opts.propagator.Extract(ctx, propagation.HeaderCarrier(conn.RequestHeader()))
+		name, startOpts := spanStartOptions(conn.Spec(), trace.SpanKindServer)
+		ctx, span := i.tracer.Start(ctx, name, startOpts...)
+
+		if i.opts.messageEvents {
+			conn = &tracedServerStream{StreamingHandlerConn: conn, events: messageEvents{span: span, enabled: true}}
+		}
+		err := next(ctx, conn)
+		finishSpan(span, err, i.opts.codeToStatus)
+		return err
+	})
+}
+
+type clientInterceptor struct {
+	opts   *options
+	tracer trace.Tracer
+}
+
+func (i *clientInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return connect.UnaryFunc(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if !req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		name, startOpts := spanStartOptions(req.Spec(), trace.SpanKindClient)
+		ctx, span := i.tracer.Start(ctx, name, startOpts...)
+		i.opts.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header()))
+
+		resp, err := next(ctx, req)
+		finishSpan(span, err, i.opts.codeToStatus)
+		return resp, err
+	})
+}
+
+func (i *clientInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return connect.StreamingClientFunc(func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		name, startOpts := spanStartOptions(spec, trace.SpanKindClient)
+		ctx, span := i.tracer.Start(ctx, name, startOpts...)
+
+		conn := next(ctx, spec)
+		i.opts.propagator.Inject(ctx, propagation.HeaderCarrier(conn.RequestHeader()))
+		s := &tracedClientStream{
+			StreamingClientConn: conn,
+			events:              messageEvents{span: span, enabled: i.opts.messageEvents},
+			codeToStatus:        i.opts.codeToStatus,
+		}
+		// Streams abandoned without being closed are finished once their context is done.
+		s.stop = context.AfterFunc(ctx, func() {
+			s.finish(contextErrToConnectErr(ctx.Err()))
+		})
+		return s
+	})
+}
+
+func (i *clientInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// messageEvents adds a span event for every message sent and received, as per the OpenTelemetry semantic
+// conventions. Send and Receive may be called concurrently on streams.
+type messageEvents struct {
+	span    trace.Span
+	enabled bool
+
+	mu             sync.Mutex
+	sent, received int
+}
+
+func (e *messageEvents) add(sent bool, err error) {
+	if !e.enabled || err != nil {
+		return
+	}
+	e.mu.Lock()
+	var (
+		id          int
+		messageType attribute.KeyValue
+	)
+	if sent {
+		e.sent++
+		id, messageType = e.sent, semconv.MessageTypeSent
+	} else {
+		e.received++
+		id, messageType = e.received, semconv.MessageTypeReceived
+	}
+	e.mu.Unlock()
+	e.span.AddEvent("message", trace.WithAttributes(messageType, semconv.MessageID(id)))
+}
+
+type tracedServerStream struct {
+	connect.StreamingHandlerConn
+
+	events messageEvents
+}
+
+func (s *tracedServerStream) Send(m any) error {
+	err := s.StreamingHandlerConn.Send(m)
+	s.events.add(true, err)
+	return err
+}
+
+func (s *tracedServerStream) Receive(m any) error {
+	err := s.StreamingHandlerConn.Receive(m)
+	s.events.add(false, err)
+	return err
+}
+
+type tracedClientStream struct {
+	connect.StreamingClientConn
+
+	events       messageEvents
+	codeToStatus CodeToStatus
+	finished     sync.Once
+	// stop stops finishing the stream when its context is done.
+	stop func() bool
+}
+
+func (s *tracedClientStream) Send(m any) error {
+	err := s.StreamingClientConn.Send(m)
+	s.events.add(true, err)
+	return err
+}
+
+func (s *tracedClientStream) Receive(m any) error {
+	err := s.StreamingClientConn.Receive(m)
+	s.events.add(false, err)
+	if err != nil {
+		s.stop()
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *tracedClientStream) CloseResponse() error {
+	err := s.StreamingClientConn.CloseResponse()
+	s.stop()
+	s.finish(err)
+	return err
+}
+
+func (s *tracedClientStream) finish(err error) {
+	s.finished.Do(func() {
+		finishSpan(s.events.span, err, s.codeToStatus)
+	})
+}
+
+func contextErrToConnectErr(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return connect.NewError(connect.CodeDeadlineExceeded, err)
+	}
+	return connect.NewError(connect.CodeCanceled, err)
+}
@@ -0,0 +1,205 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/tracing"
+)
+
+const (
+	echoProcedure   = "/test.v1.TestService/Echo"
+	streamProcedure = "/test.v1.TestService/Stream"
+)
+
+type stringClient = *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue]
+
+// setup starts a server and returns a client, both traced to the returned exporter. The echo handler fails
+// with the code found in the request message, if any.
+func setup(t *testing.T) (*tracetest.InMemoryExporter, stringClient, stringClient) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	serverInterceptors := connect.WithInterceptors(tracing.ServerInterceptor(tracing.WithTracerProvider(tp)))
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		var code connect.Code
+		if err := code.UnmarshalText([]byte(req.Msg.Value)); err == nil {
+			return nil, connect.NewError(code, errors.New("failed"))
+		}
+		return connect.NewResponse(req.Msg), nil
+	}, serverInterceptors))
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+		for i := 0; i < 3; i++ {
+			if err := stream.Send(req.Msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, serverInterceptors))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	clientInterceptors := connect.WithInterceptors(tracing.ClientInterceptor(tracing.WithTracerProvider(tp)))
+	echo := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+echoProcedure, clientInterceptors)
+	stream := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+streamProcedure, clientInterceptors)
+	return exporter, echo, stream
+}
+
+func spansByKind(t *testing.T, exporter *tracetest.InMemoryExporter) (server, client tracetest.SpanStub) {
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	for _, s := range spans {
+		switch s.SpanKind {
+		case trace.SpanKindServer:
+			server = s
+		case trace.SpanKindClient:
+			client = s
+		}
+	}
+	return server, client
+}
+
+func TestInterceptors_Unary(t *testing.T) {
+	exporter, echo, _ := setup(t)
+	if _, err := echo.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping"))); err != nil {
+		t.Fatal(err)
+	}
+
+	server, client := spansByKind(t, exporter)
+	for _, s := range []tracetest.SpanStub{server, client} {
+		if s.Name != "test.v1.TestService/Echo" {
+			t.Errorf("unexpected span name %q", s.Name)
+		}
+		if s.Status.Code != codes.Unset {
+			t.Errorf("unexpected span status %v", s.Status)
+		}
+	}
+	if server.SpanContext.TraceID() != client.SpanContext.TraceID() {
+		t.Error("expected server span to continue the client trace")
+	}
+	if server.Parent.SpanID() != client.SpanContext.SpanID() {
+		t.Error("expected server span to be a child of the client span")
+	}
+}
+
+func TestInterceptors_Status(t *testing.T) {
+	for _, tc := range []struct {
+		code         connect.Code
+		serverStatus codes.Code
+	}{
+		{code: connect.CodeInvalidArgument, serverStatus: codes.Unset},
+		{code: connect.CodeInternal, serverStatus: codes.Error},
+	} {
+		t.Run(tc.code.String(), func(t *testing.T) {
+			exporter, echo, _ := setup(t)
+			if _, err := echo.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String(tc.code.String()))); err == nil {
+				t.Fatal("expected error")
+			}
+
+			server, client := spansByKind(t, exporter)
+			if server.Status.Code != tc.serverStatus {
+				t.Errorf("expected server span status %v, got %v", tc.serverStatus, server.Status.Code)
+			}
+			if client.Status.Code != codes.Error {
+				t.Errorf("expected client span status %v, got %v", codes.Error, client.Status.Code)
+			}
+			for _, s := range []tracetest.SpanStub{server, client} {
+				var found bool
+				for _, a := range s.Attributes {
+					if a.Key == semconv.RPCConnectRPCErrorCodeKey && a.Value.AsString() == tc.code.String() {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected error code attribute on %v span", s.SpanKind)
+				}
+			}
+		})
+	}
+}
+
+func TestInterceptors_StreamMessageEvents(t *testing.T) {
+	exporter, _, stream := setup(t)
+	res, err := stream.CallServerStream(context.Background(), connect.NewRequest(wrapperspb.String("ping")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for res.Receive() {
+	}
+	if err := res.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	server, client := spansByKind(t, exporter)
+	// The server receives the request and sends 3 messages, the client sends the request and receives them.
+	for _, s := range []tracetest.SpanStub{server, client} {
+		if len(s.Events) != 4 {
+			t.Errorf("expected 4 message events on %v span, got %d", s.SpanKind, len(s.Events))
+		}
+	}
+}
+
+func TestInterceptors_AbandonedStream(t *testing.T) {
+	exporter, _, stream := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := stream.CallServerStream(ctx, connect.NewRequest(wrapperspb.String("ping")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Receive() {
+		t.Fatal(res.Err())
+	}
+	// The stream is neither drained nor closed.
+	cancel()
+
+	// Spans are exported once ended, asynchronously for the client.
+	for deadline := time.Now().Add(5 * time.Second); len(exporter.GetSpans()) < 2 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, client := spansByKind(t, exporter)
+	if client.Status.Code != codes.Error {
+		t.Errorf("expected client span status %v, got %v", codes.Error, client.Status.Code)
+	}
+	var found bool
+	for _, a := range client.Attributes {
+		if a.Key == semconv.RPCConnectRPCErrorCodeKey && a.Value.AsString() == connect.CodeCanceled.String() {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected canceled error code attribute on client span")
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	if traceID, spanID, sampled := tracing.TraceContext(context.Background()); traceID != "" || spanID != "" || sampled {
+		t.Fatalf("got %q %q %v without span, want nothing", traceID, spanID, sampled)
+	}
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "span")
+	defer span.End()
+	sc := span.SpanContext()
+	traceID, spanID, sampled := tracing.TraceContext(ctx)
+	if traceID != sc.TraceID().String() || spanID != sc.SpanID().String() || sampled != sc.IsSampled() {
+		t.Fatalf("got %q %q %v, want the IDs of the span", traceID, spanID, sampled)
+	}
+}
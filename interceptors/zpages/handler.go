@@ -0,0 +1,111 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zpages
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+var page = template.Must(template.New("zpages").Funcs(template.FuncMap{
+	"codes":  formatCodes,
+	"fields": formatFields,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>connect procedures</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+td.num { text-align: right; }
+</style>
+</head>
+<body>
+{{range .}}
+<h2>{{.Name}}</h2>
+{{with .Procedures}}
+<table>
+<tr><th>Procedure</th><th>Type</th><th>In flight</th><th>QPS 1m</th><th>QPS 10m</th><th>QPS 1h</th><th>Codes</th><th>p50</th><th>p90</th><th>p99</th><th>p99.9</th><th>max</th></tr>
+{{range .}}
+<tr>
+<td>{{.FullMethod}}</td><td>{{.Type}}</td><td class="num">{{.InFlight}}</td>
+<td class="num">{{printf "%.2f" .QPS1m}}</td><td class="num">{{printf "%.2f" .QPS10m}}</td><td class="num">{{printf "%.2f" .QPS1h}}</td>
+<td>{{codes .Codes}}</td>
+<td class="num">{{.Latency.P50}}</td><td class="num">{{.Latency.P90}}</td><td class="num">{{.Latency.P99}}</td><td class="num">{{.Latency.P999}}</td><td class="num">{{.Latency.Max}}</td>
+</tr>
+{{end}}
+</table>
+{{range .}}{{if .RecentFailures}}
+<h3>Recent failures of {{.FullMethod}}</h3>
+<table>
+<tr><th>Time</th><th>Code</th><th>Duration</th><th>Error</th><th>Fields</th></tr>
+{{range .RecentFailures}}
+<tr><td>{{.Time.Format "2006-01-02T15:04:05.000Z07:00"}}</td><td>{{.Code}}</td><td class="num">{{.Duration}}</td><td>{{.Error}}</td><td>{{fields .Fields}}</td></tr>
+{{end}}
+</table>
+{{end}}{{end}}
+{{else}}
+<p>No calls yet.</p>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+type section struct {
+	Name       string
+	Procedures []ProcedureSnapshot
+}
+
+// ServeHTTP renders the current statistics as an HTML page, or as JSON if the "format" query parameter is
+// "json".
+func (s *Stats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.Snapshot()
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(snapshot)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = page.Execute(w, []section{{Name: "Server", Procedures: snapshot.Server}, {Name: "Client", Procedures: snapshot.Client}})
+}
+
+// formatCodes formats counts by code, e.g. "ok: 12, unavailable: 3".
+func formatCodes(codes map[string]uint64) string {
+	keys := make([]string, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, code := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s: %d", code, codes[code])
+	}
+	return b.String()
+}
+
+// formatFields formats fields in logfmt style, e.g. "request_id=abc user=bob".
+func formatFields(fields logging.Fields) string {
+	var b strings.Builder
+	i := fields.Iterator()
+	for i.Next() {
+		k, v := i.At()
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%v", k, v)
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zpages
+
+import (
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the precision of the histogram: values are recorded with 2^(subBucketBits-1) linear
+// sub-buckets per power of two, i.e. with a relative error below 1/64.
+const (
+	subBucketBits  = 7
+	subBucketCount = 1 << subBucketBits
+	subBucketHalf  = subBucketCount / 2
+)
+
+// histogram is a high dynamic range (HDR) histogram of durations, recorded in microseconds. Values below
+// subBucketCount are recorded exactly, larger values in log-linear buckets: each power of two is split in
+// subBucketHalf linear sub-buckets, so that memory only grows with the logarithm of the largest value.
+type histogram struct {
+	counts []uint64
+	total  uint64
+	max    uint64
+}
+
+func bucketIndex(v uint64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits
+	return subBucketCount + (shift-1)*subBucketHalf + int(v>>shift) - subBucketHalf
+}
+
+// bucketUpperBound returns the highest value recorded in the bucket of the given index.
+func bucketUpperBound(i int) uint64 {
+	if i < subBucketCount {
+		return uint64(i)
+	}
+	shift := (i-subBucketCount)/subBucketHalf + 1
+	sub := uint64((i-subBucketCount)%subBucketHalf + subBucketHalf)
+	return (sub+1)<<shift - 1
+}
+
+func (h *histogram) record(d time.Duration) {
+	v := uint64(0)
+	if d > 0 {
+		v = uint64(d / time.Microsecond)
+	}
+	i := bucketIndex(v)
+	if i >= len(h.counts) {
+		counts := make([]uint64, i+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+	h.total++
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// percentile returns the value below which the given percentage (e.g. 99.9) of the recorded values fall.
+func (h *histogram) percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(p / 100 * float64(h.total))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			v := bucketUpperBound(i)
+			if v > h.max {
+				v = h.max
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return time.Duration(h.max) * time.Microsecond
+}
@@ -0,0 +1,45 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zpages
+
+import (
+	"context"
+
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+type options struct {
+	recentFailures  int
+	fieldsFromCtxFn func(ctx context.Context) logging.Fields
+}
+
+var defaultOptions = &options{
+	recentFailures: 10,
+}
+
+type Option func(*options)
+
+func evaluateOptions(opts []Option) *options {
+	optCopy := &options{}
+	*optCopy = *defaultOptions
+	for _, o := range opts {
+		o(optCopy)
+	}
+	return optCopy
+}
+
+// WithRecentFailures sets the number of recent failed calls kept per procedure. Defaults to 10.
+func WithRecentFailures(n int) Option {
+	return func(o *options) {
+		o.recentFailures = n
+	}
+}
+
+// WithFieldsFromContext adds the fields returned by the given function to the recorded failed calls, in
+// addition to the logging fields found in the call context (see logging.ExtractFields).
+func WithFieldsFromContext(f func(ctx context.Context) logging.Fields) Option {
+	return func(o *options) {
+		o.fieldsFromCtxFn = f
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zpages
+
+import (
+	"time"
+)
+
+const (
+	rateBucketWidth = 10 * time.Second
+	rateBuckets     = int64(time.Hour / rateBucketWidth)
+)
+
+// rateCounter counts events in a ring of 10s buckets covering the last hour, to compute rates over sliding
+// windows of up to an hour.
+type rateCounter struct {
+	buckets [rateBuckets]uint64
+	// last is the index, since the epoch, of the most recent bucket.
+	last int64
+}
+
+func bucketOf(t time.Time) int64 {
+	return t.UnixNano() / int64(rateBucketWidth)
+}
+
+// advance clears the buckets between the most recent one and the one of t, which becomes the most recent.
+func (r *rateCounter) advance(t time.Time) int64 {
+	b := bucketOf(t)
+	for i := r.last + 1; i <= b && i <= r.last+rateBuckets; i++ {
+		r.buckets[i%rateBuckets] = 0
+	}
+	if b > r.last {
+		r.last = b
+	}
+	return b
+}
+
+func (r *rateCounter) add(t time.Time) {
+	r.buckets[r.advance(t)%rateBuckets]++
+}
+
+// rate returns the number of events per second over the window ending at now. Windows starting before since,
+// when the counter was created, are shortened so that young counters aren't underestimated.
+func (r *rateCounter) rate(now time.Time, window time.Duration, since time.Time) float64 {
+	b := r.advance(now)
+	n := int64(window / rateBucketWidth)
+	var sum uint64
+	for i := b - n + 1; i <= b; i++ {
+		sum += r.buckets[i%rateBuckets]
+	}
+	// The current bucket is only partially elapsed.
+	elapsed := time.Duration(n-1)*rateBucketWidth + time.Duration(now.UnixNano()%int64(rateBucketWidth))
+	if age := now.Sub(since); age < elapsed {
+		elapsed = age
+	}
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+	return float64(sum) / elapsed.Seconds()
+}
@@ -0,0 +1,257 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zpages
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/svrana/go-connect-middleware/interceptors"
+	"github.com/svrana/go-connect-middleware/interceptors/logging"
+)
+
+var (
+	_ interceptors.ServerReportable = &Stats{}
+	_ interceptors.ClientReportable = &Stats{}
+)
+
+// Stats keeps live statistics of every procedure seen by its interceptors: rates over the last minute, 10
+// minutes and hour, calls by code, latency percentiles, calls in flight and a sample of the most recent failed
+// calls. Stats is an http.Handler rendering them, meant to be mounted on an admin mux.
+//
+// Stats is usually placed right after logging in the interceptor chain, so that failed calls are recorded
+// with the logging fields.
+type Stats struct {
+	opts *options
+
+	mu     sync.RWMutex
+	server map[string]*procedureStats
+	client map[string]*procedureStats
+}
+
+// New returns new, empty, Stats.
+func New(opts ...Option) *Stats {
+	return &Stats{
+		opts:   evaluateOptions(opts),
+		server: map[string]*procedureStats{},
+		client: map[string]*procedureStats{},
+	}
+}
+
+// Snapshot holds the statistics of every procedure seen, sorted by full method.
+type Snapshot struct {
+	Server []ProcedureSnapshot `json:"server"`
+	Client []ProcedureSnapshot `json:"client"`
+}
+
+// ProcedureSnapshot holds the statistics of a procedure.
+type ProcedureSnapshot struct {
+	FullMethod string                   `json:"full_method"`
+	Type       interceptors.ConnectType `json:"type"`
+	// InFlight is the number of calls started but not finished yet. A client stream is finished when it was
+	// received until its end, closed, or when its context is done (see interceptors.StreamClientInterceptor).
+	InFlight int64 `json:"in_flight"`
+	// QPS1m, QPS10m and QPS1h are the rates of handled calls over the last minute, 10 minutes and hour.
+	QPS1m  float64 `json:"qps_1m"`
+	QPS10m float64 `json:"qps_10m"`
+	QPS1h  float64 `json:"qps_1h"`
+	// Codes counts the handled calls by code, including "ok".
+	Codes          map[string]uint64 `json:"codes"`
+	Latency        Latency           `json:"latency"`
+	RecentFailures []FailedCall      `json:"recent_failures"`
+}
+
+// Latency holds percentiles of the handling time of calls, since the procedure was first seen.
+type Latency struct {
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+	P999 time.Duration `json:"p999"`
+	Max  time.Duration `json:"max"`
+}
+
+// FailedCall is a sample of a failed call.
+type FailedCall struct {
+	Time     time.Time      `json:"time"`
+	Code     string         `json:"code"`
+	Error    string         `json:"error"`
+	Duration time.Duration  `json:"duration"`
+	Fields   logging.Fields `json:"fields"`
+}
+
+// Snapshot returns a copy of the current statistics.
+func (s *Stats) Snapshot() Snapshot {
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return Snapshot{Server: snapshot(s.server, now), Client: snapshot(s.client, now)}
+}
+
+func snapshot(procedures map[string]*procedureStats, now time.Time) []ProcedureSnapshot {
+	s := make([]ProcedureSnapshot, 0, len(procedures))
+	for _, p := range procedures {
+		s = append(s, p.snapshot(now))
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].FullMethod < s[j].FullMethod })
+	return s
+}
+
+// ServerReporter implements interceptors.ServerReportable.
+func (s *Stats) ServerReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return s.reporter(ctx, s.server, meta), ctx
+}
+
+// ClientReporter implements interceptors.ClientReportable.
+func (s *Stats) ClientReporter(ctx context.Context, meta interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	return s.reporter(ctx, s.client, meta), ctx
+}
+
+func (s *Stats) reporter(ctx context.Context, procedures map[string]*procedureStats, meta interceptors.CallMeta) *reporter {
+	name := meta.FullMethod()
+	s.mu.RLock()
+	p, ok := procedures[name]
+	s.mu.RUnlock()
+	if !ok {
+		s.mu.Lock()
+		if p, ok = procedures[name]; !ok {
+			p = newProcedureStats(name, interceptors.ConnectTypeOf(meta.Typ), s.opts.recentFailures)
+			procedures[name] = p
+		}
+		s.mu.Unlock()
+	}
+	p.mu.Lock()
+	p.inFlight++
+	p.mu.Unlock()
+	return &reporter{ctx: ctx, opts: s.opts, procedure: p}
+}
+
+// UnaryServerInterceptor is a connect server-side interceptor that records live statistics of Unary RPCs.
+func (s *Stats) UnaryServerInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryServerInterceptor(s)
+}
+
+// StreamServerInterceptor is a connect server-side interceptor that records live statistics of Streaming RPCs.
+func (s *Stats) StreamServerInterceptor() connect.Interceptor {
+	return interceptors.StreamServerInterceptor(s)
+}
+
+// UnaryClientInterceptor is a connect client-side interceptor that records live statistics of Unary RPCs.
+func (s *Stats) UnaryClientInterceptor() connect.UnaryInterceptorFunc {
+	return interceptors.UnaryClientInterceptor(s)
+}
+
+// StreamClientInterceptor is a connect client-side interceptor that records live statistics of Streaming RPCs.
+func (s *Stats) StreamClientInterceptor() connect.Interceptor {
+	return interceptors.StreamClientInterceptor(s)
+}
+
+type procedureStats struct {
+	fullMethod string
+	typ        interceptors.ConnectType
+	since      time.Time
+
+	mu       sync.Mutex
+	inFlight int64
+	rate     rateCounter
+	codes    map[string]uint64
+	latency  histogram
+	// failures is a ring of the most recent failed calls, next is the index of the next one.
+	failures []FailedCall
+	next     int
+}
+
+func newProcedureStats(fullMethod string, typ interceptors.ConnectType, recentFailures int) *procedureStats {
+	now := time.Now()
+	p := &procedureStats{
+		fullMethod: fullMethod,
+		typ:        typ,
+		since:      now,
+		codes:      map[string]uint64{},
+		failures:   make([]FailedCall, 0, recentFailures),
+	}
+	p.rate.last = bucketOf(now)
+	return p
+}
+
+func (p *procedureStats) snapshot(now time.Time) ProcedureSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := ProcedureSnapshot{
+		FullMethod: p.fullMethod,
+		Type:       p.typ,
+		InFlight:   p.inFlight,
+		QPS1m:      p.rate.rate(now, time.Minute, p.since),
+		QPS10m:     p.rate.rate(now, 10*time.Minute, p.since),
+		QPS1h:      p.rate.rate(now, time.Hour, p.since),
+		Codes:      make(map[string]uint64, len(p.codes)),
+		Latency: Latency{
+			P50:  p.latency.percentile(50),
+			P90:  p.latency.percentile(90),
+			P99:  p.latency.percentile(99),
+			P999: p.latency.percentile(99.9),
+			Max:  p.latency.percentile(100),
+		},
+		RecentFailures: make([]FailedCall, 0, len(p.failures)),
+	}
+	for code, n := range p.codes {
+		s.Codes[code] = n
+	}
+	// Most recent failures first.
+	for i := 1; i <= len(p.failures); i++ {
+		s.RecentFailures = append(s.RecentFailures, p.failures[(p.next-i+len(p.failures))%len(p.failures)])
+	}
+	return s
+}
+
+func (p *procedureStats) addFailure(f FailedCall) {
+	if cap(p.failures) == 0 {
+		return
+	}
+	if len(p.failures) < cap(p.failures) {
+		p.failures = append(p.failures, f)
+	} else {
+		p.failures[p.next] = f
+	}
+	p.next = (p.next + 1) % cap(p.failures)
+}
+
+type reporter struct {
+	interceptors.NoopReporter
+
+	ctx       context.Context
+	opts      *options
+	procedure *procedureStats
+}
+
+func (r *reporter) PostCall(err error, rpcDuration time.Duration) {
+	now := time.Now()
+	code := interceptors.CodeString(err)
+
+	var failure *FailedCall
+	if code != interceptors.CodeOK {
+		fields := logging.ExtractFields(r.ctx)
+		if r.opts.fieldsFromCtxFn != nil {
+			fields = fields.AppendUnique(r.opts.fieldsFromCtxFn(r.ctx))
+		}
+		failure = &FailedCall{Time: now, Code: code, Error: err.Error(), Duration: rpcDuration, Fields: fields}
+	}
+
+	p := r.procedure
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.inFlight--
+	p.rate.add(now)
+	p.codes[code]++
+	p.latency.record(rpcDuration)
+	if failure != nil {
+		p.addFailure(*failure)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zpages_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/svrana/go-connect-middleware/interceptors/zpages"
+)
+
+const (
+	echoProcedure   = "/test.v1.TestService/Echo"
+	streamProcedure = "/test.v1.TestService/Stream"
+)
+
+type stringClient = *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue]
+
+// setup starts a server whose echo handler fails if the message is "fail", and whose stream handler sends
+// three messages. The client records its statistics to the returned Stats.
+func setup(t *testing.T) (*zpages.Stats, stringClient, stringClient) {
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		if req.Msg.Value == "fail" {
+			return nil, connect.NewError(connect.CodeInternal, errors.New("failed"))
+		}
+		return connect.NewResponse(req.Msg), nil
+	}))
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure, func(ctx context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+		for i := 0; i < 3; i++ {
+			if err := stream.Send(req.Msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	stats := zpages.New()
+	interceptors := connect.WithInterceptors(stats.UnaryClientInterceptor(), stats.StreamClientInterceptor())
+	echo := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+echoProcedure, interceptors)
+	stream := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+streamProcedure, interceptors)
+	return stats, echo, stream
+}
+
+func clientSnapshot(t *testing.T, stats *zpages.Stats, fullMethod string) zpages.ProcedureSnapshot {
+	for _, p := range stats.Snapshot().Client {
+		if p.FullMethod == fullMethod {
+			return p
+		}
+	}
+	t.Fatalf("no statistics for %s", fullMethod)
+	return zpages.ProcedureSnapshot{}
+}
+
+func TestUnaryStats(t *testing.T) {
+	stats, echo, _ := setup(t)
+
+	for _, msg := range []string{"ok", "ok", "fail"} {
+		_, _ = echo.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String(msg)))
+	}
+	p := clientSnapshot(t, stats, echoProcedure)
+	if p.InFlight != 0 {
+		t.Fatalf("in flight = %d, want 0", p.InFlight)
+	}
+	if p.Codes["ok"] != 2 || p.Codes["internal"] != 1 {
+		t.Fatalf("codes = %v", p.Codes)
+	}
+	if len(p.RecentFailures) != 1 || p.RecentFailures[0].Code != "internal" {
+		t.Fatalf("recent failures = %+v", p.RecentFailures)
+	}
+}
+
+func TestStreamInFlight(t *testing.T) {
+	stats, _, streamClient := setup(t)
+
+	// Closed stream.
+	stream, err := streamClient.CallServerStream(context.Background(), connect.NewRequest(wrapperspb.String("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stream.Receive() {
+		t.Fatal(stream.Err())
+	}
+	if got := clientSnapshot(t, stats, streamProcedure).InFlight; got != 1 {
+		t.Fatalf("in flight = %d, want 1 while the stream is open", got)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := clientSnapshot(t, stats, streamProcedure).InFlight; got != 0 {
+		t.Fatalf("in flight = %d, want 0 once the stream is closed", got)
+	}
+
+	// Stream abandoned without being closed, whose context is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err = streamClient.CallServerStream(ctx, connect.NewRequest(wrapperspb.String("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stream.Receive() {
+		t.Fatal(stream.Err())
+	}
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for clientSnapshot(t, stats, streamProcedure).InFlight != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the abandoned stream is still in flight after its context was canceled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := clientSnapshot(t, stats, streamProcedure).Codes["canceled"]; got != 1 {
+		t.Fatalf("canceled streams = %d, want 1", got)
+	}
+}